@@ -13,6 +13,7 @@ require (
 	github.com/valyala/fasthttp v1.56.0
 	github.com/valyala/fastjson v1.6.4
 	golang.org/x/net v0.29.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
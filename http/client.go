@@ -40,3 +40,8 @@ var client = &http.Client{
 //	//DisableCompression:    true,
 //	//ForceAttemptHTTP2:     true, // Forces HTTP/2 support
 //}
+
+// DefaultClient returns the client used by fetchers created without an explicit one.
+func DefaultClient() *http.Client {
+	return client
+}
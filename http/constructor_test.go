@@ -0,0 +1,47 @@
+package http_test
+
+import (
+	"github.com/dk-open/crypto-zip/http"
+	nethttp "net/http"
+	"testing"
+)
+
+const badURL = "http://[::1"
+
+func TestConstructorErrors(t *testing.T) {
+	c := nethttp.DefaultClient
+	if _, err := http.FetcherWithClient[symbol](c, "GET", badURL); err == nil {
+		t.Error("FetcherWithClient: expected error")
+	}
+	if _, err := http.Fetcher[symbol]("GET", badURL); err == nil {
+		t.Error("Fetcher: expected error")
+	}
+	if _, err := http.FetcherCustom(c, "GET", badURL); err == nil {
+		t.Error("FetcherCustom: expected error")
+	}
+	if _, err := http.Iterator[symbol]("GET", badURL, 1); err == nil {
+		t.Error("Iterator: expected error")
+	}
+	if _, err := http.IteratorWithClient[symbol](c, "GET", badURL, 1); err == nil {
+		t.Error("IteratorWithClient: expected error")
+	}
+	if _, err := http.MapIteratorWithClient[symbol](c, "GET", badURL, 1); err == nil {
+		t.Error("MapIteratorWithClient: expected error")
+	}
+	if _, err := http.FieldsIteratorWithClient(c, "GET", badURL, 1, []string{"symbol"}); err == nil {
+		t.Error("FieldsIteratorWithClient: expected error")
+	}
+	var res symbol
+	if err := http.FetchDefaultEncoded("GET", badURL, &res); err == nil {
+		t.Error("FetchDefaultEncoded: expected error")
+	}
+}
+
+func TestMust(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	http.Must(http.FetcherWithClient[symbol](nethttp.DefaultClient, "GET", badURL))
+}
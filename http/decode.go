@@ -0,0 +1,256 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// AcceptEncoding lists the content encodings response bodies are decoded from, see DecodeBody.
+const AcceptEncoding = "zstd,br,gzip,deflate"
+
+// maxEncodings limits the layers of a body, labelled and sniffed together.
+const maxEncodings = 4
+
+var ErrUnsupportedEncoding = errors.New("http: unsupported content encoding")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+var (
+	bufioPool  sync.Pool
+	gzipPool   sync.Pool
+	zlibPool   sync.Pool
+	flatePool  sync.Pool
+	brotliPool sync.Pool
+	zstdPool   sync.Pool
+)
+
+// DecodeBody returns the decoded body of the response. Encodings of the Content-Encoding header are
+// removed in reverse order, and a gzip or zstd body is decoded by its magic bytes even when unlabelled
+// or labelled with another encoding. Closing the reader returns the decoders to their pools and closes
+// the response body.
+func DecodeBody(resp *http.Response) (io.ReadCloser, error) {
+	res := &bodyReader{body: resp.Body}
+	r := res.buffer(resp.Body)
+
+	labels := contentEncodings(resp.Header)
+	for i := len(labels) - 1; i >= 0; i-- {
+		var err error
+		if r, err = res.decode(r, labels[i]); err != nil {
+			res.release()
+			return nil, err
+		}
+	}
+	// Servers may compress a body again or send it compressed without a label
+	for len(res.layers) < maxEncodings {
+		enc := sniff(r)
+		if enc == "" {
+			break
+		}
+		var err error
+		if r, err = res.decode(r, enc); err != nil {
+			res.release()
+			return nil, err
+		}
+	}
+	res.Reader = r
+	return res, nil
+}
+
+func contentEncodings(h http.Header) (res []string) {
+	for _, value := range h.Values("Content-Encoding") {
+		for _, enc := range strings.Split(value, ",") {
+			if enc = strings.ToLower(strings.TrimSpace(enc)); enc != "" && enc != "identity" {
+				res = append(res, enc)
+			}
+		}
+	}
+	return
+}
+
+// sniff returns the encoding of the stream by its magic bytes, empty if it has none.
+func sniff(r *bufio.Reader) string {
+	head, _ := r.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return "gzip"
+	case bytes.HasPrefix(head, zstdMagic):
+		return "zstd"
+	}
+	return ""
+}
+
+// zlibHeader reports whether the stream starts with a zlib header rather than raw deflate data.
+func zlibHeader(r *bufio.Reader) bool {
+	head, _ := r.Peek(2)
+	return len(head) == 2 && head[0]&0x0f == 8 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0
+}
+
+type bodyReader struct {
+	io.Reader
+	body   io.Closer
+	layers []func()
+}
+
+func (b *bodyReader) Close() error {
+	b.release()
+	return b.body.Close()
+}
+
+func (b *bodyReader) release() {
+	for i := len(b.layers) - 1; i >= 0; i-- {
+		b.layers[i]()
+	}
+	b.layers = nil
+}
+
+// buffer wraps the stream into a pooled reader, so that its magic bytes can be peeked.
+func (b *bodyReader) buffer(r io.Reader) *bufio.Reader {
+	br, _ := bufioPool.Get().(*bufio.Reader)
+	if br == nil {
+		br = bufio.NewReader(r)
+	} else {
+		br.Reset(r)
+	}
+	b.layers = append(b.layers, func() {
+		br.Reset(nil)
+		bufioPool.Put(br)
+	})
+	return br
+}
+
+// decode removes a layer of the encoding. A label contradicted by the magic bytes of the stream is
+// corrected, and a plain stream labelled gzip or zstd is passed through.
+func (b *bodyReader) decode(r *bufio.Reader, enc string) (*bufio.Reader, error) {
+	switch enc {
+	case "gzip", "x-gzip", "zstd":
+		if enc = sniff(r); enc == "" {
+			return r, nil
+		}
+	case "deflate":
+		if sniffed := sniff(r); sniffed != "" {
+			enc = sniffed
+		}
+	case "br":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, enc)
+	}
+
+	var dec io.Reader
+	var err error
+	switch enc {
+	case "gzip":
+		dec, err = b.gzip(r)
+	case "zstd":
+		dec, err = b.zstd(r)
+	case "deflate":
+		if zlibHeader(r) {
+			dec, err = b.zlib(r)
+		} else {
+			// Some servers send raw deflate data without the zlib header
+			dec, err = b.flate(r)
+		}
+	case "br":
+		dec = b.brotli(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.buffer(dec), nil
+}
+
+func (b *bodyReader) gzip(r io.Reader) (io.Reader, error) {
+	zr, _ := gzipPool.Get().(*gzip.Reader)
+	var err error
+	if zr == nil {
+		zr, err = gzip.NewReader(r)
+	} else {
+		err = zr.Reset(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.layers = append(b.layers, func() {
+		_ = zr.Close()
+		gzipPool.Put(zr)
+	})
+	return zr, nil
+}
+
+func (b *bodyReader) zlib(r io.Reader) (io.Reader, error) {
+	zr, _ := zlibPool.Get().(io.ReadCloser)
+	var err error
+	if zr == nil {
+		zr, err = zlib.NewReader(r)
+	} else {
+		err = zr.(zlib.Resetter).Reset(r, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.layers = append(b.layers, func() {
+		_ = zr.Close()
+		zlibPool.Put(zr)
+	})
+	return zr, nil
+}
+
+func (b *bodyReader) flate(r io.Reader) (io.Reader, error) {
+	fr, _ := flatePool.Get().(io.ReadCloser)
+	if fr == nil {
+		fr = flate.NewReader(r)
+	} else if err := fr.(flate.Resetter).Reset(r, nil); err != nil {
+		return nil, err
+	}
+	b.layers = append(b.layers, func() {
+		_ = fr.Close()
+		flatePool.Put(fr)
+	})
+	return fr, nil
+}
+
+func (b *bodyReader) brotli(r io.Reader) io.Reader {
+	br, _ := brotliPool.Get().(*brotli.Reader)
+	if br == nil {
+		br = brotli.NewReader(r)
+	} else {
+		_ = br.Reset(r)
+	}
+	b.layers = append(b.layers, func() {
+		_ = br.Reset(nil)
+		brotliPool.Put(br)
+	})
+	return br
+}
+
+func (b *bodyReader) zstd(r io.Reader) (io.Reader, error) {
+	zr, _ := zstdPool.Get().(*zstd.Decoder)
+	var err error
+	if zr == nil {
+		// A single goroutine per decoder, bodies are decoded as they are read
+		zr, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+	} else {
+		err = zr.Reset(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.layers = append(b.layers, func() {
+		_ = zr.Reset(nil)
+		zstdPool.Put(zr)
+	})
+	return zr, nil
+}
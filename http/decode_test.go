@@ -0,0 +1,144 @@
+package http_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/andybalholm/brotli"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const plainBody = `{"symbol":"BTCUSDT"}`
+
+func compress(t *testing.T, enc string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch enc {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		var err error
+		if w, err = zstd.NewWriter(&buf); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatalf("unknown encoding %s", enc)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decodeBody(contentEncoding string, body []byte) (string, error) {
+	resp := &nethttp.Response{Header: nethttp.Header{}, Body: io.NopCloser(bytes.NewReader(body))}
+	if contentEncoding != "" {
+		resp.Header.Set("Content-Encoding", contentEncoding)
+	}
+	rc, err := http.DecodeBody(resp)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return string(data), err
+}
+
+func TestDecodeBody(t *testing.T) {
+	plain := []byte(plainBody)
+	tests := []struct {
+		name, label string
+		body        []byte
+	}{
+		{"identity", "identity", plain},
+		{"unlabelled", "", plain},
+		{"gzip", "gzip", compress(t, "gzip", plain)},
+		{"deflate", "deflate", compress(t, "deflate", plain)},
+		{"raw deflate", "deflate", compress(t, "raw-deflate", plain)},
+		{"brotli", "br", compress(t, "br", plain)},
+		{"zstd", "zstd", compress(t, "zstd", plain)},
+		{"stacked", "gzip, br", compress(t, "br", compress(t, "gzip", plain))},
+		{"stacked zstd", "zstd,gzip", compress(t, "gzip", compress(t, "zstd", plain))},
+		{"unlabelled zstd", "", compress(t, "zstd", plain)},
+		{"unlabelled gzip", "", compress(t, "gzip", plain)},
+		{"zstd labelled gzip", "gzip", compress(t, "zstd", plain)},
+		{"gzip labelled deflate", "deflate", compress(t, "gzip", plain)},
+		{"plain labelled gzip", "gzip", plain},
+		{"gzip in unlabelled gzip", "gzip", compress(t, "gzip", compress(t, "gzip", plain))},
+	}
+	// Twice, so that the second round decodes with pooled decoders
+	for round := 0; round < 2; round++ {
+		for _, tt := range tests {
+			res, err := decodeBody(tt.label, tt.body)
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if res != plainBody {
+				t.Errorf("%s: expected %s, got %q", tt.name, plainBody, res)
+			}
+		}
+	}
+}
+
+func TestDecodeBodyUnsupported(t *testing.T) {
+	if _, err := decodeBody("compress", []byte(plainBody)); !errors.Is(err, http.ErrUnsupportedEncoding) {
+		t.Errorf("expected ErrUnsupportedEncoding, got %v", err)
+	}
+}
+
+func TestFetcherEncodings(t *testing.T) {
+	var accepted string
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		accepted = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "zstd")
+		_, _ = w.Write(compress(t, "zstd", []byte(plainBody)))
+	}))
+	defer srv.Close()
+
+	var res symbol
+	if err := http.Must(http.FetcherWithClient[symbol](srv.Client(), "GET", srv.URL, http.WithCompression()))(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Symbol != "BTCUSDT" {
+		t.Errorf("expected BTCUSDT, got %q", res.Symbol)
+	}
+	if accepted != http.AcceptEncoding {
+		t.Errorf("expected Accept-Encoding %q, got %q", http.AcceptEncoding, accepted)
+	}
+}
+
+func BenchmarkDecodeBody(b *testing.B) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(bytes.Repeat([]byte(plainBody), 100))
+	_ = w.Close()
+	body := buf.Bytes()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp := &nethttp.Response{Header: nethttp.Header{"Content-Encoding": {"gzip"}}, Body: io.NopCloser(bytes.NewReader(body))}
+		rc, err := http.DecodeBody(resp)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, rc)
+		_ = rc.Close()
+	}
+}
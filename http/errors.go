@@ -0,0 +1,168 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/goccy/go-json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxErrorBody is the size of the response body kept by StatusError.
+const maxErrorBody = 1 << 10
+
+// StatusError is returned for responses other than 200 OK, and for 200 OK responses carrying an exchange error
+// envelope, see WithEnvelopeCheck.
+type StatusError struct {
+	StatusCode int
+	Header     http.Header
+	// Body is the beginning of the decompressed response body.
+	Body []byte
+	// Truncated is set when the body was longer than kept.
+	Truncated bool
+	// Code and Message are the exchange error parsed from the body, empty unless an ErrorParser is set.
+	Code    string
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Code != "" || e.Message != "" {
+		return fmt.Sprintf("http: status %d: %s", e.StatusCode, strings.TrimSpace(e.Code+" "+e.Message))
+	}
+	if len(e.Body) > 0 {
+		return fmt.Sprintf("http: status %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("http: status %d", e.StatusCode)
+}
+
+// ErrorParser extracts the exchange error code and message from the body of a failed response.
+type ErrorParser func(status int, body []byte) (code, message string, ok bool)
+
+// WithErrorParser parses exchange errors of failed responses of the template into StatusError.
+func WithErrorParser(p ErrorParser) TemplateOption {
+	return func(t *Template) {
+		t.errorParser = p
+	}
+}
+
+// WithEnvelopeCheck fails 200 OK responses whose body is an exchange error, for exchanges wrapping results
+// into an envelope like {"retCode":10001,"retMsg":"params error","result":{}}. Only bodies up to 1 KiB are
+// parsed, as error envelopes are short and result lists are not.
+func WithEnvelopeCheck(p ErrorParser) TemplateOption {
+	return func(t *Template) {
+		t.envelopeParser = p
+	}
+}
+
+// JSONErrorParser parses errors of JSON objects like {"code":-1121,"msg":"Invalid symbol."}.
+// The code can be a number or a string, the message field is optional. Without the code field,
+// bodies with a message like {"message":"NotFound"} are parsed.
+func JSONErrorParser(codeField, messageField string) ErrorParser {
+	return func(status int, body []byte) (code, message string, ok bool) {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(body, &fields) != nil {
+			return
+		}
+		if codeField == "" {
+			raw, found := fields[messageField]
+			if !found {
+				return
+			}
+			message, ok = jsonScalar(raw)
+			return "", message, ok
+		}
+		raw, found := fields[codeField]
+		if !found {
+			return
+		}
+		if code, ok = jsonScalar(raw); !ok {
+			return
+		}
+		if raw, found = fields[messageField]; found {
+			message, _ = jsonScalar(raw)
+		}
+		return code, message, true
+	}
+}
+
+// JSONEnvelopeParser parses envelopes of JSON objects like {"code":"0","msg":"","data":[...]}, reporting
+// an error unless the code is the success one.
+func JSONEnvelopeParser(codeField, messageField, success string) ErrorParser {
+	parse := JSONErrorParser(codeField, messageField)
+	return func(status int, body []byte) (code, message string, ok bool) {
+		if code, message, ok = parse(status, body); !ok || code == success {
+			return "", "", false
+		}
+		return code, message, true
+	}
+}
+
+func jsonScalar(raw json.RawMessage) (string, bool) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, true
+	}
+	var n json.Number
+	if json.Unmarshal(raw, &n) == nil {
+		return n.String(), true
+	}
+	return "", false
+}
+
+// newStatusError reads the beginning of the body of the failed response, parsed by the optional parser.
+func newStatusError(resp *http.Response, p ErrorParser) *StatusError {
+	res := &StatusError{StatusCode: resp.StatusCode, Header: resp.Header}
+	var body io.Reader = resp.Body
+	if rc, err := DecodeBody(resp); err == nil {
+		defer rc.Close()
+		body = rc
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, maxErrorBody+1))
+	if len(data) > maxErrorBody {
+		data, res.Truncated = data[:maxErrorBody], true
+	}
+	res.Body = data
+
+	if p != nil {
+		if code, message, parsed := p(resp.StatusCode, data); parsed {
+			res.Code, res.Message = code, message
+		}
+	}
+	return res
+}
+
+// checkEnvelope returns the decoded body of a 200 OK response, or the error of its envelope parsed by p.
+func checkEnvelope(resp *http.Response, body io.ReadCloser, p ErrorParser) (io.ReadCloser, error) {
+	if p == nil {
+		return body, nil
+	}
+	head := make([]byte, maxErrorBody+1)
+	n, err := io.ReadFull(body, head)
+	head = head[:n]
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		if err != nil {
+			_ = body.Close()
+			return nil, err
+		}
+		// A body longer than an envelope error is a result
+		return readCloser{Reader: io.MultiReader(bytes.NewReader(head), body), Closer: body}, nil
+	}
+	if code, message, parsed := p(resp.StatusCode, head); parsed {
+		_ = body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode, Header: resp.Header, Body: head, Code: code, Message: message}
+	}
+	return readCloser{Reader: bytes.NewReader(head), Closer: body}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// IntCode returns the exchange error code as a number, e.g. -1121 for Binance.
+func (e *StatusError) IntCode() (int64, bool) {
+	v, err := strconv.ParseInt(e.Code, 10, 64)
+	return v, err == nil
+}
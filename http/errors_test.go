@@ -0,0 +1,144 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/klauspost/compress/gzip"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func errorServer(t *testing.T, status int, body string, compressed bool) *httptest.Server {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "21")
+		data := []byte(body)
+		if compressed {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, _ = zw.Write(data)
+			_ = zw.Close()
+			data = buf.Bytes()
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStatusError(t *testing.T) {
+	srv := errorServer(t, nethttp.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`, true)
+	tmpl := http.Must(http.NewTemplate("GET", srv.URL, http.WithHeaders(http.WithCompression()), http.WithErrorParser(http.JSONErrorParser("code", "msg"))))
+
+	var res tickerSymbol
+	err := http.TemplateFetcher[tickerSymbol](srv.Client(), tmpl)(context.Background(), &res)
+	var se *http.StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if se.StatusCode != 400 || se.Code != "-1121" || se.Message != "Invalid symbol." || se.Header.Get("X-MBX-USED-WEIGHT-1M") != "21" {
+		t.Errorf("unexpected error %+v", se)
+	}
+	if code, ok := se.IntCode(); !ok || code != -1121 {
+		t.Errorf("unexpected code %d", code)
+	}
+	if se.Error() != "http: status 400: -1121 Invalid symbol." {
+		t.Errorf("unexpected message %q", se.Error())
+	}
+
+	// Iterators fail the same way
+	err = http.TemplateIterator[tickerSymbol](srv.Client(), tmpl, 1)(context.Background(), func(tickerSymbol) error { return nil })
+	if !errors.As(err, &se) || se.Code != "-1121" {
+		t.Errorf("unexpected iterator error %v", err)
+	}
+}
+
+// detachedTransport sends requests with a context not derived from the one of the request.
+type detachedTransport struct{}
+
+func (detachedTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	return nethttp.DefaultTransport.RoundTrip(req.WithContext(context.Background()))
+}
+
+func TestErrorParserDetachedContext(t *testing.T) {
+	srv := errorServer(t, nethttp.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`, false)
+	tmpl := http.Must(http.NewTemplate("GET", srv.URL, http.WithErrorParser(http.JSONErrorParser("code", "msg"))))
+
+	var res tickerSymbol
+	err := http.TemplateFetcher[tickerSymbol](&nethttp.Client{Transport: detachedTransport{}}, tmpl)(context.Background(), &res)
+	var se *http.StatusError
+	if !errors.As(err, &se) || se.Code != "-1121" {
+		t.Errorf("the parser is lost with the request context: %v", err)
+	}
+}
+
+func TestStatusErrorBody(t *testing.T) {
+	srv := errorServer(t, nethttp.StatusBadGateway, "<html>"+strings.Repeat("bad gateway ", 200)+"</html>", false)
+	var res tickerSymbol
+	tmpl := http.Must(http.NewTemplate("GET", srv.URL, http.WithErrorParser(http.JSONErrorParser("code", "msg"))))
+	err := http.TemplateFetcher[tickerSymbol](srv.Client(), tmpl)(context.Background(), &res)
+	var se *http.StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if se.Code != "" || !se.Truncated || len(se.Body) != 1024 || !strings.HasPrefix(string(se.Body), "<html>bad gateway") {
+		t.Errorf("unexpected error %+v", se)
+	}
+
+	srv = errorServer(t, nethttp.StatusTeapot, `{"error":"banned"}`, false)
+	err = http.Must(http.FetcherWithClient[tickerSymbol](srv.Client(), "GET", srv.URL))(&res)
+	if !errors.As(err, &se) || se.Error() != `http: status 418: {"error":"banned"}` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestEnvelopeCheck(t *testing.T) {
+	fetcher := func(srv *httptest.Server) http.CallFetch[tickerSymbol] {
+		tmpl := http.Must(http.NewTemplate("GET", srv.URL, http.WithHeaders(http.WithCompression()),
+			http.WithEnvelopeCheck(http.JSONEnvelopeParser("retCode", "retMsg", "0"))))
+		return http.TemplateFetcher[tickerSymbol](srv.Client(), tmpl)
+	}
+
+	srv := errorServer(t, nethttp.StatusOK, `{"retCode":10001,"retMsg":"params error","result":{}}`, true)
+	var res tickerSymbol
+	err := fetcher(srv)(context.Background(), &res)
+	var se *http.StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if se.StatusCode != 200 || se.Code != "10001" || se.Message != "params error" {
+		t.Errorf("unexpected error %+v", se)
+	}
+	tmpl := http.Must(http.NewTemplate("GET", srv.URL, http.WithEnvelopeCheck(http.JSONEnvelopeParser("retCode", "retMsg", "0"))))
+	err = http.TemplateIterator[tickerSymbol](srv.Client(), tmpl, 1)(context.Background(), func(tickerSymbol) error { return nil })
+	if !errors.As(err, &se) || se.Code != "10001" {
+		t.Errorf("unexpected iterator error %v", err)
+	}
+
+	srv = errorServer(t, nethttp.StatusOK, `{"retCode":0,"retMsg":"OK","symbol":"BTCUSDT"}`, false)
+	if err = fetcher(srv)(context.Background(), &res); err != nil || res.Symbol != "BTCUSDT" {
+		t.Errorf("unexpected result %+v, %v", res, err)
+	}
+
+	// Bodies longer than an error envelope are streamed as they are
+	long := `{"retCode":0,"retMsg":"` + strings.Repeat("a", 2000) + `","symbol":"ETHUSDT"}`
+	srv = errorServer(t, nethttp.StatusOK, long, true)
+	if err = fetcher(srv)(context.Background(), &res); err != nil || res.Symbol != "ETHUSDT" {
+		t.Errorf("unexpected result %+v, %v", res, err)
+	}
+}
+
+func TestJSONErrorParserMessageOnly(t *testing.T) {
+	code, message, ok := http.JSONErrorParser("", "message")(404, []byte(`{"message":"NotFound"}`))
+	if !ok || code != "" || message != "NotFound" {
+		t.Errorf("unexpected error %q %q %v", code, message, ok)
+	}
+	if _, _, ok = http.JSONErrorParser("", "message")(502, []byte(`<html></html>`)); ok {
+		t.Error("expected no error of a non JSON body")
+	}
+}
@@ -2,14 +2,9 @@ package http
 
 import (
 	"context"
-	"fmt"
-	"github.com/andybalholm/brotli"
 	"github.com/dk-open/crypto-zip/tools/jetjson"
 	"github.com/goccy/go-json"
-	"github.com/klauspost/compress/gzip"
-	"github.com/klauspost/compress/zlib"
 	"io"
-	"log"
 	"net/http"
 )
 
@@ -18,150 +13,167 @@ type IterateFetch[TModel any] func(f func(data TModel) error) error
 
 type FetcherReader func(ctx context.Context, f func(ctx context.Context, reader io.Reader) error) error
 
-func FetcherWithClient[TModel any](c *http.Client, method string, url string, headers ...HeaderOption) FetchFunc[TModel] {
-	req, rErr := http.NewRequest(method, url, nil)
-	if rErr != nil {
-		log.Fatal(rErr)
+// Must returns the value of a constructor and panics on its error. It is meant for urls known to be valid.
+//
+//	fetch := http.Must(http.FetcherWithClient[ticker](c, "GET", tickerURL))
+func Must[F any](f F, err error) F {
+	if err != nil {
+		panic(err)
 	}
+	return f
+}
 
-	for _, h := range headers {
-		h(req)
+func FetcherWithClient[TModel any](c *http.Client, method string, url string, headers ...HeaderOption) (FetchFunc[TModel], error) {
+	t, err := NewTemplate(method, url, WithHeaders(headers...))
+	if err != nil {
+		return nil, err
 	}
-
 	return func(data *TModel) error {
-		return fetchRequest(c, req, data)
+		return fetchTemplate(context.Background(), c, t, data)
+	}, nil
+}
+
+func Fetcher[TModel any](method string, url string) (FetchFunc[TModel], error) {
+	return FetcherWithClient[TModel](client, method, url, WithCompression())
+}
+
+func FetchDefaultEncoded[TModel any](method string, url string, res *TModel) error {
+	t, err := NewTemplate(method, url, WithHeaders(WithCompression()))
+	if err != nil {
+		return err
 	}
+	return fetchTemplate(context.Background(), http.DefaultClient, t, res)
 }
 
-func Fetcher[TModel any](method string, url string) FetchFunc[TModel] {
-	req, rErr := http.NewRequest(method, url, nil)
-	if rErr != nil {
-		log.Fatal(rErr)
+type CallFetch[TModel any] func(ctx context.Context, data *TModel, opts ...CallOption) error
+type CallIterate[TModel any] func(ctx context.Context, f func(data TModel) error, opts ...CallOption) error
+
+// TemplateFetcher decodes responses of requests built from the template with the parameters of every call.
+func TemplateFetcher[TModel any](c *http.Client, t *Template) CallFetch[TModel] {
+	return func(ctx context.Context, data *TModel, opts ...CallOption) error {
+		return fetchTemplate(ctx, c, t, data, opts...)
 	}
-	req.Header.Set("Accept-Encoding", "br,gzip,deflate")
+}
 
-	return func(data *TModel) error {
-		return fetchRequest(client, req, data)
+// TemplateIterator iterates items of responses of requests built from the template, see Iterator.
+func TemplateIterator[TModel any](c *http.Client, t *Template, level int) CallIterate[TModel] {
+	return func(ctx context.Context, f func(data TModel) error, opts ...CallOption) error {
+		return iterateTemplate(ctx, c, t, jetjson.Decoder[TModel], level, f, opts...)
 	}
 }
 
-func FetchDefaultEncoded[TModel any](method string, url string, res *TModel) error {
-	req, rErr := http.NewRequest(method, url, nil)
-	if rErr != nil {
-		log.Fatal(rErr)
+// TemplateMapIterator iterates items of an object keyed by name of responses of requests built from the template,
+// see MapIteratorWithClient.
+func TemplateMapIterator[TModel any](c *http.Client, t *Template, level int) CallIterate[TModel] {
+	return func(ctx context.Context, f func(data TModel) error, opts ...CallOption) error {
+		return iterateTemplate(ctx, c, t, jetjson.MapDecoder[TModel], level, f, opts...)
 	}
-	req.Header.Set("Accept-Encoding", "br,gzip,deflate")
+}
 
-	return fetchRequest(http.DefaultClient, req, res)
+func fetchTemplate[TModel any](ctx context.Context, c *http.Client, t *Template, res *TModel, opts ...CallOption) error {
+	req, err := t.Request(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return fetchRequest(c, t, req, res)
+}
+
+func iterateTemplate[TModel any](ctx context.Context, c *http.Client, t *Template, decoder func(r io.Reader, level int) jetjson.IDecoder[TModel], level int, f func(data TModel) error, opts ...CallOption) error {
+	req, err := t.Request(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return fetchRequestIterator(c, t, req, decoder, level, f)
 }
 
-func fetchRequest[TModel any](c *http.Client, req *http.Request, res *TModel) error {
+func fetchRequest[TModel any](c *http.Client, t *Template, req *http.Request, res *TModel) error {
 	resp, err := c.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("Invalid response. Error code %d", resp.StatusCode)
+		return newStatusError(resp, t.errorParser)
 	}
 
-	switch resp.Header.Get("Content-Encoding") {
-	case "gzip":
-		rc, err := gzip.NewReader(resp.Body)
-		if err != nil {
-			return err
-		}
-		defer rc.Close()
-		return json.NewDecoder(rc).Decode(res)
-	case "deflate":
-		rc, err := zlib.NewReader(resp.Body)
-		if err != nil {
-			return err
-		}
-		defer rc.Close()
-		return json.NewDecoder(rc).Decode(res)
-	case "br":
-		return json.NewDecoder(brotli.NewReader(resp.Body)).Decode(res)
-	default:
-		return json.NewDecoder(resp.Body).Decode(res)
+	body, err := decodeResponse(resp, t.envelopeParser)
+	if err != nil {
+		return err
 	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(res)
 }
 
-func FetcherCustom(c *http.Client, method string, url string, headers ...HeaderOption) FetcherReader {
-	req, rErr := http.NewRequest(method, url, nil)
-	if rErr != nil {
-		log.Fatal(rErr)
-	}
-
-	for _, h := range headers {
-		h(req)
+func FetcherCustom(c *http.Client, method string, url string, headers ...HeaderOption) (FetcherReader, error) {
+	t, err := NewTemplate(method, url, WithHeaders(headers...))
+	if err != nil {
+		return nil, err
 	}
 
 	return func(ctx context.Context, f func(ctx context.Context, reader io.Reader) error) error {
+		req, err := t.Request(ctx)
+		if err != nil {
+			return err
+		}
 		resp, err := c.Do(req)
 		if err != nil {
 			return err
 		}
 		defer resp.Body.Close()
 		if resp.StatusCode != 200 {
-			return fmt.Errorf("Invalid response. Error code %d", resp.StatusCode)
+			return newStatusError(resp, t.errorParser)
 		}
 
-		switch resp.Header.Get("Content-Encoding") {
-		case "gzip":
-			rc, err := gzip.NewReader(resp.Body)
-			if err != nil {
-				return err
-			}
-			defer rc.Close()
-
-			return f(ctx, rc)
-		case "deflate":
-			rc, err := zlib.NewReader(resp.Body)
-			if err != nil {
-				return err
-			}
-			defer rc.Close()
-			return f(ctx, rc)
-		case "br":
-			return f(ctx, brotli.NewReader(resp.Body))
-		default:
-			return f(ctx, resp.Body)
+		body, err := decodeResponse(resp, t.envelopeParser)
+		if err != nil {
+			return err
 		}
-	}
+		defer body.Close()
+		return f(ctx, body)
+	}, nil
 }
 
-func Iterator[TModel any](method string, url string, level int, headers ...HeaderOption) IterateFetch[TModel] {
-	req, rErr := http.NewRequest(method, url, nil)
-	if rErr != nil {
-		log.Fatal(rErr)
-	}
+func Iterator[TModel any](method string, url string, level int, headers ...HeaderOption) (IterateFetch[TModel], error) {
+	return IteratorWithClient[TModel](client, method, url, level, headers...)
+}
 
-	for _, h := range headers {
-		h(req)
-	}
+func IteratorWithClient[TModel any](c *http.Client, method string, url string, level int, headers ...HeaderOption) (IterateFetch[TModel], error) {
+	return decoderIterator(c, method, url, jetjson.Decoder[TModel], level, headers)
+}
 
-	return func(f func(data TModel) error) error {
-		return fetchRequestIterator(client, req, level, f)
-	}
+// MapIteratorWithClient iterates items of an object keyed by name, see jetjson.MapDecoder.
+func MapIteratorWithClient[TModel any](c *http.Client, method string, url string, level int, headers ...HeaderOption) (IterateFetch[TModel], error) {
+	return decoderIterator(c, method, url, jetjson.MapDecoder[TModel], level, headers)
 }
 
-func IteratorWithClient[TModel any](c *http.Client, method string, url string, level int, headers ...HeaderOption) IterateFetch[TModel] {
-	req, rErr := http.NewRequest(method, url, nil)
-	if rErr != nil {
-		log.Fatal(rErr)
+// FieldsIteratorWithClient iterates items reading fields of the given names, see jetjson.FieldsDecoder.
+func FieldsIteratorWithClient(c *http.Client, method string, url string, level int, names []string, headers ...HeaderOption) (IterateFetch[[]string], error) {
+	decoder := func(r io.Reader, level int) jetjson.IDecoder[[]string] {
+		return jetjson.FieldsDecoder(r, level, names...)
 	}
+	return decoderIterator(c, method, url, decoder, level, headers)
+}
 
-	for _, h := range headers {
-		h(req)
+func decoderIterator[TModel any](c *http.Client, method string, url string, decoder func(r io.Reader, level int) jetjson.IDecoder[TModel], level int, headers []HeaderOption) (IterateFetch[TModel], error) {
+	t, err := NewTemplate(method, url, WithHeaders(headers...))
+	if err != nil {
+		return nil, err
 	}
-
 	return func(f func(data TModel) error) error {
-		return fetchRequestIterator(c, req, level, f)
+		return iterateTemplate(context.Background(), c, t, decoder, level, f)
+	}, nil
+}
+
+// decodeResponse returns the decoded body of a 200 OK response checked for an error envelope by the optional parser.
+func decodeResponse(resp *http.Response, envelope ErrorParser) (io.ReadCloser, error) {
+	body, err := DecodeBody(resp)
+	if err != nil {
+		return nil, err
 	}
+	return checkEnvelope(resp, body, envelope)
 }
 
-func fetchRequestIterator[TModel any](c *http.Client, req *http.Request, level int, f func(data TModel) error) error {
+func fetchRequestIterator[TModel any](c *http.Client, t *Template, req *http.Request, decoder func(r io.Reader, level int) jetjson.IDecoder[TModel], level int, f func(data TModel) error) error {
 	resp, err := c.Do(req)
 	if err != nil {
 		return err
@@ -169,27 +181,13 @@ func fetchRequestIterator[TModel any](c *http.Client, req *http.Request, level i
 
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("Invalid response. Error code %d", resp.StatusCode)
+		return newStatusError(resp, t.errorParser)
 	}
 
-	switch resp.Header.Get("Content-Encoding") {
-	case "gzip":
-		rc, rErr := gzip.NewReader(resp.Body)
-		if rErr != nil {
-			return rErr
-		}
-		defer rc.Close()
-		return jetjson.Decoder[TModel](rc, level).Read(f)
-	case "deflate":
-		rc, rErr := zlib.NewReader(resp.Body)
-		if rErr != nil {
-			return rErr
-		}
-		defer rc.Close()
-		return jetjson.Decoder[TModel](rc, level).Read(f)
-	case "br":
-		return jetjson.Decoder[TModel](brotli.NewReader(resp.Body), level).Read(f)
-	default:
-		return jetjson.Decoder[TModel](resp.Body, level).Read(f)
+	body, err := decodeResponse(resp, t.envelopeParser)
+	if err != nil {
+		return err
 	}
+	defer body.Close()
+	return decoder(body, level).Read(f)
 }
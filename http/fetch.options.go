@@ -10,8 +10,9 @@ func WithHeader(key string, value string) func(req *http.Request) {
 	}
 }
 
+// WithCompression accepts the encodings of AcceptEncoding.
 func WithCompression() HeaderOption {
 	return func(req *http.Request) {
-		req.Header.Set("Accept-Encoding", "gzip,deflate,br")
+		req.Header.Set("Accept-Encoding", AcceptEncoding)
 	}
 }
@@ -25,7 +25,7 @@ func TestFetcherWithDifferentEncodings(t *testing.T) {
 
 	client := createMockClient(preCompressed)
 
-	fetcher := http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithCompression())
+	fetcher := http.Must(http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithCompression()))
 	var res []testSymbolModel
 	if err = fetcher(&res); err != nil {
 		t.Fatalf("Failed to fetch data: %v", err)
@@ -44,7 +44,7 @@ func TestFetcherWithDifferentEncodings(t *testing.T) {
 
 func TestFetcherFastIterate(t *testing.T) {
 	targetUrl := "https://api.binance.com/api/v3/ticker/bookTicker"
-	fetcher2 := http.Iterator[testSymbolModel]("GET", targetUrl, 1, http.WithCompression())
+	fetcher2 := http.Must(http.Iterator[testSymbolModel]("GET", targetUrl, 1, http.WithCompression()))
 	if err := fetcher2(func(data testSymbolModel) error {
 		//fmt.Println(data)
 		return nil
@@ -63,15 +63,15 @@ func BenchmarkFetcher(b *testing.B) {
 
 	client := createMockClient(preCompressed)
 
-	fetcherGzip := http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "gzip"))
+	fetcherGzip := http.Must(http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "gzip")))
 	//fetcherZstd := http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "zstd"))
-	fetcherGzipFast := http.IteratorWithClient[testSymbolModel](client, "GET", targetUrl, 1, http.WithHeader("Accept-Encoding", "gzip"))
-	fetcherBrFast := http.IteratorWithClient[testSymbolModel](client, "GET", targetUrl, 1, http.WithHeader("Accept-Encoding", "br"))
-	fetcherPlainFast := http.IteratorWithClient[testSymbolModel](client, "GET", targetUrl, 1)
-	fetcherBr := http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "br"))
-	fetcherDeflate := http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "deflate"))
-	fetcherPlain := http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl)
-	fetcherCustom := http.FetcherCustom(client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "br"))
+	fetcherGzipFast := http.Must(http.IteratorWithClient[testSymbolModel](client, "GET", targetUrl, 1, http.WithHeader("Accept-Encoding", "gzip")))
+	fetcherBrFast := http.Must(http.IteratorWithClient[testSymbolModel](client, "GET", targetUrl, 1, http.WithHeader("Accept-Encoding", "br")))
+	fetcherPlainFast := http.Must(http.IteratorWithClient[testSymbolModel](client, "GET", targetUrl, 1))
+	fetcherBr := http.Must(http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "br")))
+	fetcherDeflate := http.Must(http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "deflate")))
+	fetcherPlain := http.Must(http.FetcherWithClient[[]testSymbolModel](client, "GET", targetUrl))
+	fetcherCustom := http.Must(http.FetcherCustom(client, "GET", targetUrl, http.WithHeader("Accept-Encoding", "br")))
 
 	b.Run("GZip", func(b *testing.B) {
 		b.ResetTimer()
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("http: rate limit reached")
+
+// Limit is a budget of request weight per window aligned to the clock, like the Binance minute.
+type Limit struct {
+	Weight int
+	Window time.Duration
+	// Path limits the budget to requests of a single endpoint. Empty applies to all requests.
+	Path string
+	// Header is the response header reporting the weight the server counted in the current window,
+	// e.g. X-MBX-USED-WEIGHT-1M. It corrects the local count when set.
+	Header string
+}
+
+// Limits are the rate limits an exchange declares for its endpoints.
+type Limits struct {
+	Limits []Limit
+	// Costs is the weight of requests by path, DefaultCost is used for other paths.
+	Costs       map[string]int
+	DefaultCost int
+	// Reject fails calls exceeding a limit with ErrRateLimited instead of delaying them.
+	Reject bool
+	// BanDelay is the pause after 429 and 418 responses without Retry-After, a minute by default.
+	BanDelay time.Duration
+}
+
+type window struct {
+	start time.Time
+	used  int
+}
+
+// Governor keeps requests of an exchange within its limits. It is shared by all clients of the exchange,
+// as limits are counted per IP by servers.
+type Governor struct {
+	limits Limits
+
+	mu      sync.Mutex
+	windows []window
+	retryAt time.Time
+}
+
+func NewGovernor(limits Limits) *Governor {
+	if limits.BanDelay <= 0 {
+		limits.BanDelay = time.Minute
+	}
+	return &Governor{limits: limits, windows: make([]window, len(limits.Limits))}
+}
+
+// Client returns a copy of the client sending requests through the governor. Waiting for the budget
+// does not count toward the client timeout, it applies to the request once it is sent.
+func (g *Governor) Client(c *http.Client) *http.Client {
+	res := *c
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res.Transport = &governedTransport{g: g, base: base, timeout: c.Timeout}
+	res.Timeout = 0
+	return &res
+}
+
+func (g *Governor) cost(path string) int {
+	if cost, ok := g.limits.Costs[path]; ok {
+		return cost
+	}
+	return max(g.limits.DefaultCost, 1)
+}
+
+// roll starts the window of the limit containing now.
+func (g *Governor) roll(i int, now time.Time) *window {
+	w := &g.windows[i]
+	if start := now.Truncate(g.limits.Limits[i].Window); start.After(w.start) {
+		w.start, w.used = start, 0
+	}
+	return w
+}
+
+// Reserve takes the weight of a request to the path, waiting until the budget is available
+// unless the limits reject such calls.
+func (g *Governor) Reserve(ctx context.Context, path string) error {
+	cost := g.cost(path)
+	for {
+		wait := g.tryReserve(path, cost, time.Now())
+		if wait <= 0 {
+			return nil
+		}
+		if g.limits.Reject {
+			return fmt.Errorf("%w, retry in %v", ErrRateLimited, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// tryReserve takes the weight or returns the time to wait for it.
+func (g *Governor) tryReserve(path string, cost int, now time.Time) (wait time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if now.Before(g.retryAt) {
+		return g.retryAt.Sub(now)
+	}
+	for i, l := range g.limits.Limits {
+		if l.Path != "" && l.Path != path {
+			continue
+		}
+		w := g.roll(i, now)
+		// A request costing more than the limit is sent into an empty window
+		if w.used > 0 && w.used+cost > l.Weight {
+			wait = max(wait, w.start.Add(l.Window).Sub(now))
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+	for i, l := range g.limits.Limits {
+		if l.Path == "" || l.Path == path {
+			g.windows[i].used += cost
+		}
+	}
+	return 0
+}
+
+// Update applies the weight reported by the server and backs off on 429 and 418 responses.
+func (g *Governor) Update(path string, resp *http.Response) {
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, l := range g.limits.Limits {
+		if l.Header == "" || l.Path != "" && l.Path != path {
+			continue
+		}
+		if used, err := strconv.Atoi(resp.Header.Get(l.Header)); err == nil {
+			w := g.roll(i, now)
+			// Requests still in flight are counted locally only
+			w.used = max(w.used, used)
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		if retryAt := now.Add(retryAfter(resp.Header.Get("Retry-After"), now, g.limits.BanDelay)); retryAt.After(g.retryAt) {
+			g.retryAt = retryAt
+		}
+	}
+}
+
+// retryAfter parses the Retry-After header given in seconds or as a date.
+func retryAfter(value string, now time.Time, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(now)
+	}
+	return def
+}
+
+type governedTransport struct {
+	g       *Governor
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *governedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.g.Reserve(req.Context(), req.URL.Path); err != nil {
+		return nil, err
+	}
+	cancel := context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+		req = req.WithContext(ctx)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	t.g.Update(req.URL.Path, resp)
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the request timeout once the body is read.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
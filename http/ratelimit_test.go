@@ -0,0 +1,171 @@
+package http_test
+
+import (
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type limitServer struct {
+	requests atomic.Int32
+	// usedWeight is reported in X-MBX-USED-WEIGHT-1M when set
+	usedWeight atomic.Int32
+	status     atomic.Int32
+	retryAfter string
+}
+
+func (s *limitServer) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	s.requests.Add(1)
+	if used := s.usedWeight.Load(); used > 0 {
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", strconv.Itoa(int(used)))
+	}
+	if status := s.status.Load(); status != 0 {
+		if s.retryAfter != "" {
+			w.Header().Set("Retry-After", s.retryAfter)
+		}
+		w.WriteHeader(int(status))
+		return
+	}
+	_, _ = w.Write([]byte(`{"symbol":"BTCUSDT"}`))
+}
+
+type symbol struct {
+	Symbol string `json:"symbol"`
+}
+
+func fetch(c *nethttp.Client, url string) error {
+	var res symbol
+	return http.Must(http.FetcherWithClient[symbol](c, "GET", url))(&res)
+}
+
+func TestGovernorRejectsByCost(t *testing.T) {
+	server := &limitServer{}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	g := http.NewGovernor(http.Limits{
+		Limits: []http.Limit{{Weight: 50, Window: time.Hour}},
+		Costs:  map[string]int{"/heavy": 20},
+		Reject: true,
+	})
+	c := g.Client(&nethttp.Client{Timeout: time.Second})
+	for i := 0; i < 2; i++ {
+		if err := fetch(c, srv.URL+"/heavy"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fetch(c, srv.URL+"/heavy"); !errors.Is(err, http.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	// Cheap requests still fit
+	if err := fetch(c, srv.URL+"/light"); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.requests.Load(); n != 3 {
+		t.Errorf("expected 3 requests sent, got %d", n)
+	}
+}
+
+func TestGovernorUsesServerWeight(t *testing.T) {
+	server := &limitServer{}
+	server.usedWeight.Store(5992)
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	g := http.NewGovernor(http.Limits{
+		Limits:      []http.Limit{{Weight: 6000, Window: time.Hour, Header: "X-MBX-USED-WEIGHT-1M"}},
+		DefaultCost: 5,
+		Reject:      true,
+	})
+	c := g.Client(&nethttp.Client{})
+	if err := fetch(c, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := fetch(c, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := fetch(c, srv.URL); !errors.Is(err, http.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited once the server weight is reached, got %v", err)
+	}
+}
+
+func TestGovernorPerEndpointLimit(t *testing.T) {
+	srv := httptest.NewServer(&limitServer{})
+	defer srv.Close()
+
+	g := http.NewGovernor(http.Limits{
+		Limits: []http.Limit{{Weight: 1, Window: time.Hour, Path: "/tickers"}},
+		Reject: true,
+	})
+	c := g.Client(&nethttp.Client{})
+	if err := fetch(c, srv.URL+"/tickers"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fetch(c, srv.URL+"/tickers"); !errors.Is(err, http.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if err := fetch(c, srv.URL+"/instruments"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGovernorDelaysWithinWindow(t *testing.T) {
+	srv := httptest.NewServer(&limitServer{})
+	defer srv.Close()
+
+	window := 200 * time.Millisecond
+	g := http.NewGovernor(http.Limits{Limits: []http.Limit{{Weight: 2, Window: window}}})
+	// The client timeout must not include the wait for the next window
+	c := g.Client(&nethttp.Client{Timeout: 100 * time.Millisecond})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := fetch(c, srv.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 2*window {
+		t.Errorf("waited %v for the next window", elapsed)
+	}
+	if time.Now().Truncate(window).Equal(start.Truncate(window)) {
+		t.Errorf("third request sent in the first window")
+	}
+}
+
+func TestGovernorBacksOff(t *testing.T) {
+	server := &limitServer{retryAfter: "1"}
+	server.status.Store(nethttp.StatusTooManyRequests)
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	g := http.NewGovernor(http.Limits{Reject: true})
+	c := g.Client(&nethttp.Client{})
+	if err := fetch(c, srv.URL); err == nil || errors.Is(err, http.ErrRateLimited) {
+		t.Fatalf("expected the 429 response error, got %v", err)
+	}
+	server.status.Store(0)
+	if err := fetch(c, srv.URL); !errors.Is(err, http.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited during Retry-After, got %v", err)
+	}
+	if n := server.requests.Load(); n != 1 {
+		t.Errorf("expected no requests during Retry-After, got %d", n)
+	}
+
+	// Waiting governors send the request once Retry-After has passed
+	server.status.Store(nethttp.StatusTeapot)
+	waiting := http.NewGovernor(http.Limits{})
+	wc := waiting.Client(&nethttp.Client{})
+	_ = fetch(wc, srv.URL)
+	server.status.Store(0)
+	start := time.Now()
+	if err := fetch(wc, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("request sent %v after a ban of 1 second", elapsed)
+	}
+}
@@ -0,0 +1,250 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/goccy/go-json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Template describes requests of an endpoint. Every call builds its own *http.Request from the template,
+// so calls never share a request and can run concurrently.
+type Template struct {
+	method  string
+	url     *url.URL
+	headers []HeaderOption
+	encoder BodyEncoder
+	signer  Signer
+	// errorParser and envelopeParser read exchange errors of responses, see WithErrorParser and WithEnvelopeCheck
+	errorParser    ErrorParser
+	envelopeParser ErrorParser
+}
+
+type TemplateOption func(t *Template)
+
+// WithHeaders applies the header options to every request of the template.
+func WithHeaders(headers ...HeaderOption) TemplateOption {
+	return func(t *Template) {
+		t.headers = append(t.headers, headers...)
+	}
+}
+
+// WithEncoder sets the encoder of request bodies, JSONBody by default.
+func WithEncoder(e BodyEncoder) TemplateOption {
+	return func(t *Template) {
+		t.encoder = e
+	}
+}
+
+// WithSigner signs every request of the template.
+func WithSigner(s Signer) TemplateOption {
+	return func(t *Template) {
+		t.signer = s
+	}
+}
+
+// NewTemplate creates the template of requests to the url. The query of the url is sent with every request.
+func NewTemplate(method string, rawURL string, opts ...TemplateOption) (*Template, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	res := &Template{method: method, url: u, encoder: JSONBody}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res, nil
+}
+
+// Call holds parameters of a single request.
+type Call struct {
+	// query parameters in the order they are sent
+	query  [][2]string
+	header http.Header
+	body   any
+}
+
+type CallOption func(c *Call)
+
+// Query adds the query parameter to the request.
+func Query(key, value string) CallOption {
+	return func(c *Call) {
+		c.query = append(c.query, [2]string{key, value})
+	}
+}
+
+// Header sets the header of the request.
+func Header(key, value string) CallOption {
+	return func(c *Call) {
+		if c.header == nil {
+			c.header = http.Header{}
+		}
+		c.header.Set(key, value)
+	}
+}
+
+// Body sets the value encoded into the request body by the encoder of the template.
+func Body(v any) CallOption {
+	return func(c *Call) {
+		c.body = v
+	}
+}
+
+// Request builds a new request of the template with the call parameters.
+func (t *Template) Request(ctx context.Context, opts ...CallOption) (*http.Request, error) {
+	var call Call
+	for _, opt := range opts {
+		opt(&call)
+	}
+
+	u := *t.url
+	// The template query is kept as written, call parameters are appended in order
+	if len(call.query) > 0 {
+		u.RawQuery = appendQuery(u.RawQuery, call.query...)
+	}
+
+	var body []byte
+	var reader io.Reader
+	if call.body != nil {
+		var err error
+		if body, err = t.encoder.Encode(call.body); err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, t.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	if call.body != nil {
+		req.Header.Set("Content-Type", t.encoder.ContentType())
+	}
+	for _, h := range t.headers {
+		h(req)
+	}
+	for key, values := range call.header {
+		req.Header[key] = values
+	}
+	if t.signer != nil {
+		if err = t.signer.Sign(req, body); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+func appendQuery(rawQuery string, params ...[2]string) string {
+	var sb strings.Builder
+	sb.WriteString(rawQuery)
+	for _, p := range params {
+		if sb.Len() > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(url.QueryEscape(p[0]))
+		sb.WriteByte('=')
+		sb.WriteString(url.QueryEscape(p[1]))
+	}
+	return sb.String()
+}
+
+// BodyEncoder encodes values of request bodies.
+type BodyEncoder interface {
+	ContentType() string
+	Encode(v any) ([]byte, error)
+}
+
+var (
+	// JSONBody encodes values as JSON.
+	JSONBody BodyEncoder = jsonEncoder{}
+	// FormBody encodes url.Values or map[string]string values as a form.
+	FormBody BodyEncoder = formEncoder{}
+)
+
+var ErrUnsupportedBody = errors.New("http: unsupported body value")
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string {
+	return "application/json"
+}
+
+func (jsonEncoder) Encode(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type formEncoder struct{}
+
+func (formEncoder) ContentType() string {
+	return "application/x-www-form-urlencoded"
+}
+
+func (formEncoder) Encode(v any) ([]byte, error) {
+	switch values := v.(type) {
+	case url.Values:
+		return []byte(values.Encode()), nil
+	case map[string]string:
+		res := make(url.Values, len(values))
+		for key, value := range values {
+			res.Set(key, value)
+		}
+		return []byte(res.Encode()), nil
+	}
+	return nil, ErrUnsupportedBody
+}
+
+// Signer authenticates requests of private endpoints. The body is the encoded request body, nil without one.
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// HMACSigner signs requests the way Binance and Bitrue do. It adds the timestamp and the receive window
+// to the query and appends the hex HMAC-SHA256 signature of the query followed by the body.
+type HMACSigner struct {
+	Key    string
+	Secret string
+	// KeyHeader carries the API key, X-MBX-APIKEY by default.
+	KeyHeader string
+	// RecvWindow is the time the request stays valid for the server, omitted when zero.
+	RecvWindow time.Duration
+	// Now is the time source of timestamps, time.Now by default. It can be corrected by the exchange clock.
+	Now func() time.Time
+}
+
+// NewHMACSigner creates the signer with the API key and secret.
+func NewHMACSigner(key, secret string) *HMACSigner {
+	return &HMACSigner{Key: key, Secret: secret}
+}
+
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	var params [][2]string
+	if s.RecvWindow > 0 {
+		params = append(params, [2]string{"recvWindow", strconv.FormatInt(s.RecvWindow.Milliseconds(), 10)})
+	}
+	params = append(params, [2]string{"timestamp", strconv.FormatInt(now().UnixMilli(), 10)})
+	query := appendQuery(req.URL.RawQuery, params...)
+
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte(query))
+	mac.Write(body)
+	req.URL.RawQuery = appendQuery(query, [2]string{"signature", hex.EncodeToString(mac.Sum(nil))})
+
+	keyHeader := s.KeyHeader
+	if keyHeader == "" {
+		keyHeader = "X-MBX-APIKEY"
+	}
+	req.Header.Set(keyHeader, s.Key)
+	return nil
+}
@@ -0,0 +1,122 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+type echo struct {
+	Method      string `json:"method"`
+	Query       string `json:"query"`
+	ContentType string `json:"contentType"`
+	Body        string `json:"body"`
+	Key         string `json:"key"`
+}
+
+func echoServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = fmt.Fprintf(w, `{"method":%q,"query":%q,"contentType":%q,"body":%q,"key":%q}`,
+			r.Method, r.URL.RawQuery, r.Header.Get("Content-Type"), body, r.Header.Get("X-MBX-APIKEY"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTemplateConcurrentCalls(t *testing.T) {
+	srv := echoServer(t)
+	tmpl, err := http.NewTemplate("GET", srv.URL+"/api/v3/depth?limit=5", http.WithHeaders(http.WithCompression()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetch := http.TemplateFetcher[echo](srv.Client(), tmpl)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var res echo
+			symbol := fmt.Sprintf("SYM%d", i)
+			if err := fetch(context.Background(), &res, http.Query("symbol", symbol), http.Query("page", "2")); err != nil {
+				errs <- err
+				return
+			}
+			if expected := "limit=5&symbol=" + symbol + "&page=2"; res.Query != expected {
+				errs <- fmt.Errorf("expected query %s, got %s", expected, res.Query)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestTemplateBody(t *testing.T) {
+	srv := echoServer(t)
+	for _, c := range []struct {
+		encoder     http.BodyEncoder
+		body        any
+		contentType string
+		expected    string
+	}{
+		{http.JSONBody, map[string]any{"symbol": "BTCUSDT", "qty": 1.5}, "application/json", `{"qty":1.5,"symbol":"BTCUSDT"}`},
+		{http.FormBody, url.Values{"symbol": {"BTCUSDT"}, "side": {"BUY"}}, "application/x-www-form-urlencoded", "side=BUY&symbol=BTCUSDT"},
+	} {
+		tmpl, err := http.NewTemplate("POST", srv.URL+"/api/v3/order", http.WithEncoder(c.encoder))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res echo
+		if err = http.TemplateFetcher[echo](srv.Client(), tmpl)(context.Background(), &res, http.Body(c.body)); err != nil {
+			t.Fatal(err)
+		}
+		if res.Method != "POST" || res.ContentType != c.contentType || res.Body != c.expected {
+			t.Errorf("unexpected request %+v", res)
+		}
+	}
+
+	tmpl, _ := http.NewTemplate("POST", srv.URL, http.WithEncoder(http.FormBody))
+	if _, err := tmpl.Request(context.Background(), http.Body(42)); err != http.ErrUnsupportedBody {
+		t.Errorf("expected unsupported body, got %v", err)
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	srv := echoServer(t)
+	// The example of the Binance signed endpoint documentation
+	signer := http.NewHMACSigner("vmPUZE6mv9SD5VNHk4HlWFsOr6aKE2zvsw0MuIgwCIPy6utIco14y7Ju91duEh8A", "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j")
+	signer.RecvWindow = 5 * time.Second
+	signer.Now = func() time.Time { return time.UnixMilli(1499827319559) }
+	tmpl, err := http.NewTemplate("POST", srv.URL+"/api/v3/order", http.WithSigner(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res echo
+	err = http.TemplateFetcher[echo](srv.Client(), tmpl)(context.Background(), &res,
+		http.Query("symbol", "LTCBTC"), http.Query("side", "BUY"), http.Query("type", "LIMIT"),
+		http.Query("timeInForce", "GTC"), http.Query("quantity", "1"), http.Query("price", "0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559" +
+		"&signature=c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71"
+	if res.Query != expected {
+		t.Errorf("expected query %s, got %s", expected, res.Query)
+	}
+	if res.Key != signer.Key {
+		t.Errorf("expected api key header, got %q", res.Key)
+	}
+}
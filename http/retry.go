@@ -0,0 +1,216 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy retries failed requests with exponential backoff and full jitter. Only idempotent
+// requests are retried: GET, HEAD, OPTIONS, PUT and DELETE, and requests with an Idempotency-Key header.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one.
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// Statuses are response codes worth another attempt. Network errors are always retried.
+	Statuses map[int]bool
+	// NonIdempotent allows retries of all methods, e.g. for POST endpoints which only read.
+	NonIdempotent bool
+
+	requests  atomic.Int64
+	attempts  atomic.Int64
+	retries   atomic.Int64
+	recovered atomic.Int64
+	exhausted atomic.Int64
+}
+
+// RetryStats are counters of requests sent through a retry policy.
+type RetryStats struct {
+	Requests int64
+	Attempts int64
+	Retries  int64
+	// Recovered counts requests which succeeded after a retry.
+	Recovered int64
+	// Exhausted counts requests which still failed after retries.
+	Exhausted int64
+}
+
+type RetryOption func(p *RetryPolicy)
+
+// WithMaxAttempts sets the number of attempts including the first one.
+func WithMaxAttempts(n int) RetryOption {
+	return func(p *RetryPolicy) {
+		p.MaxAttempts = n
+	}
+}
+
+// WithRetryBackoff sets the delay range between attempts.
+func WithRetryBackoff(min, max time.Duration) RetryOption {
+	return func(p *RetryPolicy) {
+		p.MinBackoff, p.MaxBackoff = min, max
+	}
+}
+
+// WithRetryStatuses replaces the retryable response codes.
+func WithRetryStatuses(codes ...int) RetryOption {
+	return func(p *RetryPolicy) {
+		p.Statuses = make(map[int]bool, len(codes))
+		for _, code := range codes {
+			p.Statuses[code] = true
+		}
+	}
+}
+
+// WithNonIdempotentRetries retries requests of all methods.
+func WithNonIdempotentRetries() RetryOption {
+	return func(p *RetryPolicy) {
+		p.NonIdempotent = true
+	}
+}
+
+// NewRetryPolicy creates a policy of 3 attempts between 100ms and 5s apart, retrying 429 and 5xx gateway errors.
+func NewRetryPolicy(opts ...RetryOption) *RetryPolicy {
+	res := &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  100 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+	}
+	WithRetryStatuses(http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout)(res)
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// Client returns a copy of the client retrying requests. The client timeout applies to every attempt.
+// Wrap a governed client, so that every attempt takes its weight: policy.Client(governor.Client(c)).
+// Exchange adapters do so with the policy of exchange.WithRetry.
+func (p *RetryPolicy) Client(c *http.Client) *http.Client {
+	res := *c
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res.Transport = &retryTransport{p: p, base: base, timeout: c.Timeout}
+	res.Timeout = 0
+	return &res
+}
+
+// Stats returns counters of the policy.
+func (p *RetryPolicy) Stats() RetryStats {
+	return RetryStats{
+		Requests:  p.requests.Load(),
+		Attempts:  p.attempts.Load(),
+		Retries:   p.retries.Load(),
+		Recovered: p.recovered.Load(),
+		Exhausted: p.exhausted.Load(),
+	}
+}
+
+// Idempotent reports whether the request can be sent again without side effects.
+func Idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+func (p *RetryPolicy) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// Attempts cancelled by the caller and calls rejected by the governor fail at once
+		return req.Context().Err() == nil && !errors.Is(err, ErrRateLimited)
+	}
+	return p.Statuses[resp.StatusCode]
+}
+
+// backoff returns the delay before the attempt following the given one, starting from zero.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	d := p.MinBackoff
+	for i := 0; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d > p.MinBackoff {
+		d = p.MinBackoff + time.Duration(rand.Int63n(int64(d-p.MinBackoff)))
+	}
+	if resp != nil {
+		if after := retryAfter(resp.Header.Get("Retry-After"), time.Now(), 0); after > d {
+			d = after
+		}
+	}
+	return d
+}
+
+type retryTransport struct {
+	p       *RetryPolicy
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.p.requests.Add(1)
+	// A body can be sent again only when it can be recreated
+	retryable := (t.p.NonIdempotent || Idempotent(req)) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+		t.p.attempts.Add(1)
+		resp, err := t.send(attemptReq)
+
+		if !retryable || attempt+1 >= t.p.MaxAttempts || !t.p.retryable(req, resp, err) {
+			if attempt > 0 {
+				if err == nil && resp.StatusCode < 400 {
+					t.p.recovered.Add(1)
+				} else {
+					t.p.exhausted.Add(1)
+				}
+			}
+			return resp, err
+		}
+
+		delay := t.p.backoff(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			_ = resp.Body.Close()
+		}
+		t.p.retries.Add(1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send runs a single attempt within the timeout of the client.
+func (t *retryTransport) send(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
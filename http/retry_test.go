@@ -0,0 +1,144 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first requests with the status, or drops their connections when the status is zero.
+type flakyServer struct {
+	failures int32
+	status   int
+	requests atomic.Int32
+	bodies   []string
+}
+
+func (s *flakyServer) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	n := s.requests.Add(1)
+	body, _ := io.ReadAll(r.Body)
+	s.bodies = append(s.bodies, string(body))
+	if n <= s.failures {
+		if s.status == 0 {
+			conn, _, _ := w.(nethttp.Hijacker).Hijack()
+			_ = conn.Close()
+			return
+		}
+		w.WriteHeader(s.status)
+		return
+	}
+	_, _ = w.Write([]byte(`{"symbol":"BTCUSDT"}`))
+}
+
+type tickerSymbol struct {
+	Symbol string `json:"symbol"`
+}
+
+func retryClient(t *testing.T, s *flakyServer, opts ...http.RetryOption) (*nethttp.Client, *http.RetryPolicy, string) {
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	policy := http.NewRetryPolicy(append([]http.RetryOption{http.WithRetryBackoff(time.Millisecond, 10*time.Millisecond)}, opts...)...)
+	return policy.Client(srv.Client()), policy, srv.URL
+}
+
+func TestRetryRecovers(t *testing.T) {
+	for _, status := range []int{nethttp.StatusServiceUnavailable, 0} {
+		s := &flakyServer{failures: 2, status: status}
+		c, policy, url := retryClient(t, s)
+		var res tickerSymbol
+		if err := http.Must(http.FetcherWithClient[tickerSymbol](c, "GET", url))(&res); err != nil {
+			t.Fatalf("status %d: %v", status, err)
+		}
+		if res.Symbol != "BTCUSDT" {
+			t.Errorf("unexpected response %v", res)
+		}
+		expected := http.RetryStats{Requests: 1, Attempts: 3, Retries: 2, Recovered: 1}
+		if stats := policy.Stats(); stats != expected {
+			t.Errorf("status %d: expected %+v, got %+v", status, expected, stats)
+		}
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	s := &flakyServer{failures: 10, status: nethttp.StatusInternalServerError}
+	c, policy, url := retryClient(t, s, http.WithMaxAttempts(4))
+	var res tickerSymbol
+	if err := http.Must(http.FetcherWithClient[tickerSymbol](c, "GET", url))(&res); err == nil {
+		t.Fatal("expected the error of the last attempt")
+	}
+	if s.requests.Load() != 4 {
+		t.Errorf("expected 4 attempts, got %d", s.requests.Load())
+	}
+	if stats := policy.Stats(); stats.Exhausted != 1 || stats.Retries != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRetryStatuses(t *testing.T) {
+	s := &flakyServer{failures: 1, status: nethttp.StatusBadRequest}
+	c, _, url := retryClient(t, s)
+	var res tickerSymbol
+	if err := http.Must(http.FetcherWithClient[tickerSymbol](c, "GET", url))(&res); err == nil {
+		t.Fatal("expected a bad request")
+	}
+	if s.requests.Load() != 1 {
+		t.Errorf("client errors must not be retried, got %d attempts", s.requests.Load())
+	}
+
+	s = &flakyServer{failures: 1, status: nethttp.StatusBadRequest}
+	c, _, url = retryClient(t, s, http.WithRetryStatuses(nethttp.StatusBadRequest))
+	if err := http.Must(http.FetcherWithClient[tickerSymbol](c, "GET", url))(&res); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetryIdempotency(t *testing.T) {
+	s := &flakyServer{failures: 1, status: nethttp.StatusServiceUnavailable}
+	c, _, url := retryClient(t, s)
+	tmpl, err := http.NewTemplate("POST", url+"/api/v3/order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := http.TemplateFetcher[tickerSymbol](c, tmpl)
+	var res tickerSymbol
+	if err = order(context.Background(), &res, http.Body(map[string]string{"symbol": "BTCUSDT"})); err == nil {
+		t.Fatal("POST must not be retried")
+	}
+
+	s = &flakyServer{failures: 1, status: nethttp.StatusServiceUnavailable}
+	c, _, url = retryClient(t, s)
+	tmpl, _ = http.NewTemplate("POST", url+"/api/v3/order")
+	order = http.TemplateFetcher[tickerSymbol](c, tmpl)
+	if err = order(context.Background(), &res, http.Body(map[string]string{"symbol": "BTCUSDT"}), http.Header("Idempotency-Key", "order-1")); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.bodies) != 2 || s.bodies[0] != s.bodies[1] || s.bodies[1] != `{"symbol":"BTCUSDT"}` {
+		t.Errorf("expected the body sent twice, got %q", s.bodies)
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	s := &flakyServer{failures: 10, status: nethttp.StatusServiceUnavailable}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	policy := http.NewRetryPolicy(http.WithRetryBackoff(time.Minute, time.Minute))
+	tmpl, _ := http.NewTemplate("GET", srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var res tickerSymbol
+	start := time.Now()
+	err := http.TemplateFetcher[tickerSymbol](policy.Client(srv.Client()), tmpl)(ctx, &res)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("backoff outlived the context")
+	}
+}
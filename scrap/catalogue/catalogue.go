@@ -0,0 +1,194 @@
+// Package catalogue assigns stable asset and market ids to markets of all exchanges.
+//
+// The catalogue is persisted as JSON only. It is saved when markets are listed, not per frame, and its
+// aliases are edited by hand. A binary form reusing the smart MarketTable record does not fit: the table
+// keys markets by 32 bit ids, while catalogue market ids are 48 bit asset pairs, and it has no room for
+// assets and aliases. Frames already carry their markets in the binary table record.
+package catalogue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"strings"
+	"sync"
+)
+
+// maxAssetID is the largest id fitting into the asset bits of market ids.
+const maxAssetID = types.AssetID(1<<types.AssetBits - 1)
+
+var ErrTooManyAssets = errors.New("catalogue: asset ids exhausted")
+
+// defaultAliases reconciles exchange specific asset codes with canonical names.
+var defaultAliases = map[string]string{
+	"XBT":        "BTC",
+	"XXBT":       "BTC",
+	"XDG":        "DOGE",
+	"XXDG":       "DOGE",
+	"XETH":       "ETH",
+	"ZUSD":       "USD",
+	"ZEUR":       "EUR",
+	"USDT-ERC20": "USDT",
+	"USDT-TRC20": "USDT",
+	"USDC-ERC20": "USDC",
+}
+
+// Catalogue holds ids of assets and markets. Ids are assigned on the first sight of a name and never change,
+// so the catalogue has to be saved and loaded to keep them stable across restarts. It is safe for concurrent use.
+type Catalogue struct {
+	mu      sync.RWMutex
+	assets  map[string]types.AssetID
+	names   map[types.AssetID]string
+	aliases map[string]string
+	// markets of every exchange by the exchange market name
+	markets map[types.ExchangeID]map[string]types.MarketID
+	last    types.AssetID
+}
+
+func New() *Catalogue {
+	res := &Catalogue{
+		assets:  map[string]types.AssetID{},
+		names:   map[types.AssetID]string{},
+		aliases: map[string]string{},
+		markets: map[types.ExchangeID]map[string]types.MarketID{},
+	}
+	for alias, name := range defaultAliases {
+		res.aliases[alias] = name
+	}
+	return res
+}
+
+// normalize returns the canonical name of the asset.
+func (c *Catalogue) normalize(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if canonical, ok := c.aliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// Alias resolves the alias to the canonical asset name, e.g. XBT to BTC.
+func (c *Catalogue) Alias(alias, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aliases[strings.ToUpper(alias)] = c.normalize(name)
+}
+
+// Asset returns the id of the asset, assigning the next free one to an unknown asset.
+func (c *Catalogue) Asset(name string) (types.AssetID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.asset(name)
+}
+
+func (c *Catalogue) asset(name string) (types.AssetID, error) {
+	name = c.normalize(name)
+	if id, ok := c.assets[name]; ok {
+		return id, nil
+	}
+	if c.last >= maxAssetID {
+		return 0, ErrTooManyAssets
+	}
+	c.last++
+	c.assets[name] = c.last
+	c.names[c.last] = name
+	return c.last, nil
+}
+
+// Lookup returns the id of a known asset or of its alias.
+func (c *Catalogue) Lookup(name string) (types.AssetID, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	id, ok := c.assets[c.normalize(name)]
+	return id, ok
+}
+
+// Name returns the canonical name of the asset.
+func (c *Catalogue) Name(id types.AssetID) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	name, ok := c.names[id]
+	return name, ok
+}
+
+// AssetMap returns the ids of all assets by their canonical names and aliases.
+func (c *Catalogue) AssetMap() types.AssetMap {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	res := make(types.AssetMap, len(c.assets)+len(c.aliases))
+	for name, id := range c.assets {
+		res.Add(id, name)
+	}
+	for alias, name := range c.aliases {
+		res.Alias(alias, name)
+	}
+	return res
+}
+
+// BaseName is the name of the asset traded on the market. Derivatives are assets of their own,
+// so a perpetual and a future of the same pair never share a market id.
+func BaseName(m exchange.Market) string {
+	switch m.Type {
+	case exchange.Perpetual:
+		return m.Base + ".PERP"
+	case exchange.Future:
+		return m.Base + ".F" + m.Expiry.UTC().Format("20060102")
+	}
+	return m.Base
+}
+
+// AddMarkets assigns ids to markets of the exchange and returns them in the order of markets. Markets of
+// any status get ids, so a market keeps its id through a halt.
+func (c *Catalogue) AddMarkets(exchangeID types.ExchangeID, markets []exchange.Market) ([]types.ExchangeMarketID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	known, ok := c.markets[exchangeID]
+	if !ok {
+		known = make(map[string]types.MarketID, len(markets))
+		c.markets[exchangeID] = known
+	}
+
+	res := make([]types.ExchangeMarketID, 0, len(markets))
+	for _, m := range markets {
+		// Aliases are resolved before the derivative suffix, so XBT.PERP is BTC.PERP
+		m.Base = c.normalize(m.Base)
+		base, err := c.asset(BaseName(m))
+		if err != nil {
+			return nil, err
+		}
+		quote, err := c.asset(m.Quote)
+		if err != nil {
+			return nil, err
+		}
+		known[m.Name] = types.NewMarket(base, quote)
+		res = append(res, types.ExchangeMarketByAssets(exchangeID.ID(), base, quote))
+	}
+	return res, nil
+}
+
+// Market returns the id of the market by its name on the exchange.
+func (c *Catalogue) Market(exchangeID types.ExchangeID, name string) (types.ExchangeMarketID, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	id, ok := c.markets[exchangeID][name]
+	if !ok {
+		return 0, false
+	}
+	return types.ExchangeMarketByAssets(exchangeID.ID(), id.Sell(), id.Buy()), true
+}
+
+// Build adds markets of all exchanges.
+func (c *Catalogue) Build(ctx context.Context, exchanges ...exchange.Exchange) error {
+	for _, ex := range exchanges {
+		markets, err := ex.Markets(ctx)
+		if err != nil {
+			return fmt.Errorf("catalogue: %s markets: %w", ex.Name(), err)
+		}
+		if _, err = c.AddMarkets(ex.ID(), markets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
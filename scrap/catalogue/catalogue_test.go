@@ -0,0 +1,180 @@
+package catalogue_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/catalogue"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/binance"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"github.com/dk-open/crypto-zip/scrap/exchange/kraken"
+	"github.com/dk-open/crypto-zip/types"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testExchanges(t *testing.T) []exchange.Exchange {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v1/exchangeInfo":  {"../exchange/binance/testdata/exchangeInfo.json"},
+		"/fapi/v1/exchangeInfo": {"../exchange/binance/testdata/fapiExchangeInfo.json"},
+		"/0/public/AssetPairs":  {"../exchange/kraken/testdata/AssetPairs.json"},
+	})
+	return []exchange.Exchange{
+		http.Must(binance.New(exchange.WithBaseURL(srv.URL))),
+		http.Must(binance.NewFutures(exchange.WithBaseURL(srv.URL))),
+		http.Must(kraken.New(exchange.WithBaseURL(srv.URL))),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	c := catalogue.New()
+	if err := c.Build(context.Background(), testExchanges(t)...); err != nil {
+		t.Fatal(err)
+	}
+
+	btc, ok := c.Lookup("BTC")
+	if !ok {
+		t.Fatal("BTC is not catalogued")
+	}
+	if xbt, _ := c.Lookup("XBT"); xbt != btc {
+		t.Errorf("XBT is not an alias of BTC")
+	}
+	if name, _ := c.Name(btc); name != "BTC" {
+		t.Errorf("unexpected name %s of BTC", name)
+	}
+
+	// ETH/BTC is the same market on both spot exchanges
+	binanceETH, ok1 := c.Market(binance.ID, "ETHBTC")
+	krakenETH, ok2 := c.Market(kraken.ID, "XETHXXBT")
+	if !ok1 || !ok2 || binanceETH.Market() != krakenETH.Market() {
+		t.Errorf("ETH/BTC markets differ: %v %v", binanceETH, krakenETH)
+	}
+	if binanceETH.Exchange() != binance.ID || binanceETH.Buy() != btc {
+		t.Errorf("unexpected ETH/BTC market %v", binanceETH)
+	}
+
+	perp, _ := c.Market(binance.FuturesID, "BTCUSDT")
+	future, _ := c.Market(binance.FuturesID, "BTCUSDT_241227")
+	spot, _ := c.Market(binance.ID, "BTCUSDT")
+	if perp == future || perp.Market() == spot.Market() {
+		t.Errorf("derivatives share market ids: %v %v %v", spot, perp, future)
+	}
+	if name, _ := c.Name(future.Sell()); name != "BTC.F20241227" {
+		t.Errorf("unexpected future asset %s", name)
+	}
+	if _, ok = c.Market(kraken.ID, "XREPZEUR"); ok {
+		t.Errorf("offline market must not be catalogued")
+	}
+}
+
+func TestStableIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalogue.json")
+	c, err := catalogue.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Alias("WBTC-BEP20", "WBTC")
+	if err = c.Build(context.Background(), testExchanges(t)...); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := catalogue.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Markets seen in a different order after the restart keep their ids
+	exchanges := testExchanges(t)
+	for i := len(exchanges) - 1; i >= 0; i-- {
+		if err = loaded.Build(context.Background(), exchanges[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, id := range c.AssetMap() {
+		if loadedID, ok := loaded.Lookup(name); !ok || loadedID != id {
+			t.Errorf("asset %s changed id from %d to %d", name, id, loadedID)
+		}
+	}
+	for _, name := range []string{"BTCUSDT", "ETHBTC", "SHIBUSDT"} {
+		before, _ := c.Market(binance.ID, name)
+		after, ok := loaded.Market(binance.ID, name)
+		if !ok || before != after {
+			t.Errorf("market %s changed id from %v to %v", name, before, after)
+		}
+	}
+
+	sol, _ := loaded.Lookup("SOL")
+	id, err := loaded.Asset("ADA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, known := c.Lookup("ADA"); known || id <= sol {
+		t.Errorf("new asset must get the next id, got %d", id)
+	}
+	wbtc := mustAsset(t, loaded, "WBTC")
+	if id, _ = loaded.Lookup("wbtc-bep20"); id != wbtc {
+		t.Errorf("aliases are not persisted")
+	}
+}
+
+func mustAsset(t *testing.T, c *catalogue.Catalogue, name string) types.AssetID {
+	t.Helper()
+	id, err := c.Asset(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestBaseName(t *testing.T) {
+	expiry := time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC)
+	for m, name := range map[exchange.Market]string{
+		{Base: "BTC"}:                                        "BTC",
+		{Base: "BTC", Type: exchange.Perpetual}:              "BTC.PERP",
+		{Base: "ETH", Type: exchange.Future, Expiry: expiry}: "ETH.F20250328",
+	} {
+		if res := catalogue.BaseName(m); res != name {
+			t.Errorf("expected %s, got %s", name, res)
+		}
+	}
+}
+
+func TestAliasedDerivatives(t *testing.T) {
+	c := catalogue.New()
+	c.Alias("XBT", "BTC")
+	expiry := time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC)
+	ids, err := c.AddMarkets(binance.FuturesID, []exchange.Market{
+		{Name: "BTCUSDT", Base: "BTC", Quote: "USDT", Type: exchange.Perpetual},
+		{Name: "BTCUSDT_250328", Base: "BTC", Quote: "USDT", Type: exchange.Future, Expiry: expiry},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliased, err := c.AddMarkets(kraken.ID, []exchange.Market{
+		{Name: "PF_XBTUSDT", Base: "XBT", Quote: "USDT", Type: exchange.Perpetual},
+		{Name: "FF_XBTUSDT_250328", Base: "xbt", Quote: "USDT", Type: exchange.Future, Expiry: expiry},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range ids {
+		if ids[i].Market() != aliased[i].Market() {
+			t.Errorf("market %d of the aliased base differs: %v %v", i, ids[i], aliased[i])
+		}
+	}
+	if _, ok := c.Lookup("XBT.PERP"); ok {
+		t.Error("the aliased perpetual got an asset of its own")
+	}
+}
+
+func TestBuildCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := catalogue.New().Build(ctx, testExchanges(t)...); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the build canceled, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package catalogue
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/types"
+	"github.com/goccy/go-json"
+	"os"
+	"path/filepath"
+)
+
+// catalogueJSON is the persistent form of the catalogue.
+type catalogueJSON struct {
+	Assets  map[string]types.AssetID                       `json:"assets"`
+	Aliases map[string]string                              `json:"aliases"`
+	Markets map[types.ExchangeID]map[string]types.MarketID `json:"markets"`
+}
+
+func (c *Catalogue) MarshalJSON() ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return json.Marshal(catalogueJSON{Assets: c.assets, Aliases: c.aliases, Markets: c.markets})
+}
+
+func (c *Catalogue) UnmarshalJSON(data []byte) error {
+	var v catalogueJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	res := New()
+	for name, id := range v.Assets {
+		if id == 0 || id > maxAssetID {
+			return fmt.Errorf("catalogue: invalid id %d of asset %s", id, name)
+		}
+		if other, ok := res.names[id]; ok {
+			return fmt.Errorf("catalogue: assets %s and %s share id %d", name, other, id)
+		}
+		res.assets[name] = id
+		res.names[id] = name
+		res.last = max(res.last, id)
+	}
+	for alias, name := range v.Aliases {
+		res.aliases[alias] = name
+	}
+	for exchangeID, markets := range v.Markets {
+		res.markets[exchangeID] = markets
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.assets, c.names, c.aliases, c.markets, c.last = res.assets, res.names, res.aliases, res.markets, res.last
+	return nil
+}
+
+// Load reads the catalogue saved to the path. A new catalogue is returned if the file does not exist.
+func Load(path string) (*Catalogue, error) {
+	res := New()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = res.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Save writes the catalogue to the path. The file is replaced atomically, so a crash never loses assigned ids.
+func (c *Catalogue) Save(path string) error {
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,80 @@
+// Package depth maintains order books from snapshots and diff streams.
+package depth
+
+import (
+	"sort"
+	"time"
+)
+
+// Level is the total quantity at a price.
+type Level struct {
+	Price float64
+	Qty   float64
+}
+
+// Book is the order book of a market. Bids are kept in descending and asks in ascending price order.
+type Book struct {
+	bids []Level
+	asks []Level
+	// UpdateID is the id of the last update applied to the book.
+	UpdateID uint64
+}
+
+func (b *Book) reset(s Snapshot) {
+	b.bids, b.asks = b.bids[:0], b.asks[:0]
+	for _, l := range s.Bids {
+		b.SetBid(l)
+	}
+	for _, l := range s.Asks {
+		b.SetAsk(l)
+	}
+	b.UpdateID = s.UpdateID
+}
+
+// SetBid sets the quantity of the bid level, a zero quantity removes the level.
+func (b *Book) SetBid(l Level) {
+	i := sort.Search(len(b.bids), func(i int) bool { return b.bids[i].Price <= l.Price })
+	b.bids = set(b.bids, i, l)
+}
+
+// SetAsk sets the quantity of the ask level, a zero quantity removes the level.
+func (b *Book) SetAsk(l Level) {
+	i := sort.Search(len(b.asks), func(i int) bool { return b.asks[i].Price >= l.Price })
+	b.asks = set(b.asks, i, l)
+}
+
+// set updates the level at the position the price belongs to.
+func set(levels []Level, i int, l Level) []Level {
+	found := i < len(levels) && levels[i].Price == l.Price
+	switch {
+	case found && l.Qty == 0:
+		return append(levels[:i], levels[i+1:]...)
+	case found:
+		levels[i].Qty = l.Qty
+		return levels
+	case l.Qty == 0:
+		return levels
+	}
+	levels = append(levels, Level{})
+	copy(levels[i+1:], levels[i:])
+	levels[i] = l
+	return levels
+}
+
+// Top returns copies of up to n best levels of both sides.
+func (b *Book) Top(n int) (bids, asks []Level) {
+	bids = append([]Level(nil), b.bids[:min(n, len(b.bids))]...)
+	asks = append([]Level(nil), b.asks[:min(n, len(b.asks))]...)
+	return
+}
+
+// Depth returns the number of bid and ask levels.
+func (b *Book) Depth() (bids, asks int) {
+	return len(b.bids), len(b.asks)
+}
+
+// Record returns the record of up to n best levels of the book.
+func (b *Book) Record(marketID uint32, ts time.Time, n int) Record {
+	bids, asks := b.Top(n)
+	return Record{MarketID: marketID, Time: ts, UpdateID: b.UpdateID, Bids: bids, Asks: asks}
+}
@@ -0,0 +1,171 @@
+package depth
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/dk-open/crypto-zip/compress"
+	"io"
+	"math"
+	"time"
+)
+
+// RecordDepth is the kind of book snapshot records. Depth records are written to a stream of their own:
+// records carry no length, so a smart.Book reader cannot skip them and fails on the kind. The kind is
+// distinct from the frame and record kinds of the smart format, so a stream is told by its first byte.
+const RecordDepth byte = 6
+
+// maxDecimals bounds the precision of prices and quantities in records.
+const maxDecimals = 18
+
+var ErrInvalidRecord = errors.New("depth: invalid record")
+
+// Record is a snapshot of the top levels of a market book.
+type Record struct {
+	MarketID uint32
+	Time     time.Time
+	UpdateID uint64
+	Bids     []Level
+	Asks     []Level
+}
+
+// WriteRecord encodes the record with prices and quantities quantized to the precisions, which are powers of 10.
+// The layout is kind | market id | unix ms | update id | price decimals | quantity decimals | bids count | asks count,
+// followed by levels from the best ones. The first price of a side is absolute and the next ones are
+// distances from the previous level, so deep books of small ticks stay compact.
+func WriteRecord(buf io.ByteWriter, r Record, pricePrecision, qtyPrecision float64) error {
+	priceDecimals, pOK := decimals(pricePrecision)
+	qtyDecimals, qOK := decimals(qtyPrecision)
+	if !pOK || !qOK {
+		return ErrInvalidRecord
+	}
+	if err := buf.WriteByte(RecordDepth); err != nil {
+		return err
+	}
+	for _, v := range []uint64{uint64(r.MarketID), uint64(r.Time.UnixMilli()), r.UpdateID} {
+		if err := compress.WriteVariant(buf, v); err != nil {
+			return err
+		}
+	}
+	if err := buf.WriteByte(priceDecimals); err != nil {
+		return err
+	}
+	if err := buf.WriteByte(qtyDecimals); err != nil {
+		return err
+	}
+	if err := compress.WriteVariant(buf, uint64(len(r.Bids))); err != nil {
+		return err
+	}
+	if err := compress.WriteVariant(buf, uint64(len(r.Asks))); err != nil {
+		return err
+	}
+	if err := writeLevels(buf, r.Bids, pricePrecision, qtyPrecision, true); err != nil {
+		return err
+	}
+	return writeLevels(buf, r.Asks, pricePrecision, qtyPrecision, false)
+}
+
+func writeLevels(buf io.ByteWriter, levels []Level, pricePrecision, qtyPrecision float64, desc bool) error {
+	var prev uint64
+	for i, l := range levels {
+		price := uint64(math.Round(l.Price * pricePrecision))
+		v := price
+		switch {
+		case i == 0:
+		case desc && price <= prev:
+			v = prev - price
+		case !desc && price >= prev:
+			v = price - prev
+		default:
+			// Levels are out of order
+			return ErrInvalidRecord
+		}
+		if err := compress.WriteVariant(buf, v); err != nil {
+			return err
+		}
+		if err := compress.WriteVariant(buf, uint64(math.Round(l.Qty*qtyPrecision))); err != nil {
+			return err
+		}
+		prev = price
+	}
+	return nil
+}
+
+// ReadRecord reads a record following the kind byte.
+func ReadRecord(r io.ByteReader) (res Record, err error) {
+	var header [3]uint64
+	for i := range header {
+		if header[i], err = binary.ReadUvarint(r); err != nil {
+			return res, unexpectedEOF(err)
+		}
+	}
+	res.MarketID, res.Time, res.UpdateID = uint32(header[0]), time.UnixMilli(int64(header[1])), header[2]
+
+	priceDecimals, err := r.ReadByte()
+	if err != nil {
+		return res, unexpectedEOF(err)
+	}
+	qtyDecimals, err := r.ReadByte()
+	if err != nil {
+		return res, unexpectedEOF(err)
+	}
+	if priceDecimals > maxDecimals || qtyDecimals > maxDecimals {
+		return res, ErrInvalidRecord
+	}
+	pricePrecision, qtyPrecision := math.Pow10(int(priceDecimals)), math.Pow10(int(qtyDecimals))
+
+	nBids, err := binary.ReadUvarint(r)
+	if err != nil {
+		return res, unexpectedEOF(err)
+	}
+	nAsks, err := binary.ReadUvarint(r)
+	if err != nil {
+		return res, unexpectedEOF(err)
+	}
+	if res.Bids, err = readLevels(r, nBids, pricePrecision, qtyPrecision, true); err != nil {
+		return
+	}
+	res.Asks, err = readLevels(r, nAsks, pricePrecision, qtyPrecision, false)
+	return
+}
+
+func readLevels(r io.ByteReader, n uint64, pricePrecision, qtyPrecision float64, desc bool) ([]Level, error) {
+	// The count is not trusted for allocation, every level takes at least two bytes
+	res := make([]Level, 0, min(n, 1024))
+	var price uint64
+	for i := uint64(0); i < n; i++ {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		qty, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		switch {
+		case i == 0:
+			price = v
+		case desc:
+			price -= v
+		default:
+			price += v
+		}
+		res = append(res, Level{Price: float64(price) / pricePrecision, Qty: float64(qty) / qtyPrecision})
+	}
+	return res, nil
+}
+
+// decimals returns the number of decimals of the precision, false unless it is a power of 10 up to maxDecimals.
+func decimals(precision float64) (byte, bool) {
+	if precision < 1 {
+		return 0, false
+	}
+	d := math.Round(math.Log10(precision))
+	return byte(d), d <= maxDecimals && math.Pow10(int(d)) == precision
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
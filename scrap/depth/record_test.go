@@ -0,0 +1,61 @@
+package depth_test
+
+import (
+	"bytes"
+	"github.com/dk-open/crypto-zip/scrap/depth"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	rec := depth.Record{
+		MarketID: 42,
+		Time:     time.UnixMilli(1_729_339_200_123),
+		UpdateID: 51_234_567_890,
+		Bids:     []depth.Level{{68015.55, 0.12345}, {68015.54, 1}, {68010, 2.5}},
+		Asks:     []depth.Level{{68015.56, 0.5}, {68015.6, 0.00001}},
+	}
+	var buf bytes.Buffer
+	if err := depth.WriteRecord(&buf, rec, 1e2, 1e5); err != nil {
+		t.Fatal(err)
+	}
+	// Header of 19 bytes and levels of 2 to 6 bytes
+	if buf.Len() > 45 {
+		t.Errorf("record of 5 levels takes %d bytes", buf.Len())
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	if kind, _ := r.ReadByte(); kind != depth.RecordDepth {
+		t.Fatalf("unexpected record kind %d", kind)
+	}
+	res, err := depth.ReadRecord(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Time.Equal(rec.Time) {
+		t.Errorf("expected time %v, got %v", rec.Time, res.Time)
+	}
+	res.Time = rec.Time
+	if !reflect.DeepEqual(res, rec) {
+		t.Fatalf("expected %v, got %v", rec, res)
+	}
+}
+
+func TestRecordRejectsUnorderedLevels(t *testing.T) {
+	rec := depth.Record{Bids: []depth.Level{{1, 1}, {2, 1}}}
+	var buf bytes.Buffer
+	if err := depth.WriteRecord(&buf, rec, 1, 1); err != depth.ErrInvalidRecord {
+		t.Fatalf("expected ErrInvalidRecord, got %v", err)
+	}
+}
+
+func TestRecordRejectsPrecisionNotPowerOf10(t *testing.T) {
+	rec := depth.Record{Bids: []depth.Level{{Price: 2, Qty: 1}}}
+	for _, precision := range [][2]float64{{2, 1}, {5e3, 1}, {100, 0.5}, {1e-2, 1}} {
+		var buf bytes.Buffer
+		if err := depth.WriteRecord(&buf, rec, precision[0], precision[1]); err != depth.ErrInvalidRecord {
+			t.Errorf("precisions %v: expected ErrInvalidRecord, got %v", precision, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package depth
+
+import (
+	"errors"
+)
+
+// ErrGap is returned when an update does not follow the previous one. The book is out of sync
+// until a new snapshot is applied.
+var ErrGap = errors.New("depth: update sequence gap")
+
+// ErrStaleSnapshot is returned for a snapshot older than buffered updates, a newer one has to be fetched.
+var ErrStaleSnapshot = errors.New("depth: snapshot older than updates")
+
+// Snapshot is the full order book as of the update id.
+type Snapshot struct {
+	UpdateID uint64
+	Bids     []Level
+	Asks     []Level
+}
+
+// Update is a diff event covering update ids from FirstID to LastID, like U and u of Binance depth streams.
+// Levels with zero quantity are removed.
+type Update struct {
+	FirstID uint64
+	LastID  uint64
+	Bids    []Level
+	Asks    []Level
+}
+
+// Sync maintains a book from a snapshot and the diff stream following the Binance procedure:
+// updates are buffered until a snapshot is applied, updates older than the snapshot are dropped,
+// and every next update has to start right after the previous one.
+type Sync struct {
+	book   Book
+	buffer []Update
+	synced bool
+	// first is set until the first update after the snapshot is applied,
+	// which may start before the snapshot id.
+	first bool
+}
+
+func NewSync() *Sync {
+	return &Sync{}
+}
+
+// Synced reports whether the book is in sync with the stream.
+func (s *Sync) Synced() bool {
+	return s.synced
+}
+
+// Book returns the book. It is valid while Synced.
+func (s *Sync) Book() *Book {
+	return &s.book
+}
+
+// Update applies the update or buffers it until a snapshot is applied. On ErrGap the update is buffered
+// and the book stays out of sync until the next snapshot.
+func (s *Sync) Update(u Update) error {
+	if !s.synced {
+		s.buffer = append(s.buffer, u)
+		return nil
+	}
+	if err := s.apply(u); err != nil {
+		s.synced = false
+		s.buffer = append(s.buffer[:0], u)
+		return err
+	}
+	return nil
+}
+
+// Snapshot applies the snapshot and the buffered updates following it.
+func (s *Sync) Snapshot(snap Snapshot) error {
+	buffer := s.buffer[:0]
+	for _, u := range s.buffer {
+		if u.LastID > snap.UpdateID {
+			buffer = append(buffer, u)
+		}
+	}
+	s.buffer = buffer
+	if len(s.buffer) > 0 && s.buffer[0].FirstID > snap.UpdateID+1 {
+		return ErrStaleSnapshot
+	}
+
+	s.book.reset(snap)
+	s.first = true
+	for i, u := range s.buffer {
+		if err := s.apply(u); err != nil {
+			s.buffer = append(s.buffer[:0], s.buffer[i:]...)
+			return err
+		}
+	}
+	s.buffer = s.buffer[:0]
+	s.synced = true
+	return nil
+}
+
+func (s *Sync) apply(u Update) error {
+	next := s.book.UpdateID + 1
+	if s.first {
+		if u.LastID < next {
+			return nil
+		}
+		if u.FirstID > next {
+			return ErrGap
+		}
+	} else if u.FirstID != next {
+		return ErrGap
+	}
+	for _, l := range u.Bids {
+		s.book.SetBid(l)
+	}
+	for _, l := range u.Asks {
+		s.book.SetAsk(l)
+	}
+	s.book.UpdateID = u.LastID
+	s.first = false
+	return nil
+}
@@ -0,0 +1,79 @@
+package depth_test
+
+import (
+	"errors"
+	"github.com/dk-open/crypto-zip/scrap/depth"
+	"reflect"
+	"testing"
+)
+
+func update(first, last uint64, bids, asks []depth.Level) depth.Update {
+	return depth.Update{FirstID: first, LastID: last, Bids: bids, Asks: asks}
+}
+
+func TestSync(t *testing.T) {
+	s := depth.NewSync()
+	// Buffered until the snapshot, the first one is older than the snapshot
+	for _, u := range []depth.Update{
+		update(98, 100, []depth.Level{{100, 9}}, nil),
+		update(101, 103, []depth.Level{{101, 2}, {99.5, 0}}, []depth.Level{{102, 0}}),
+	} {
+		if err := s.Update(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Synced() {
+		t.Fatal("synced without a snapshot")
+	}
+	err := s.Snapshot(depth.Snapshot{
+		UpdateID: 101,
+		Bids:     []depth.Level{{99.5, 1}, {100, 3}},
+		Asks:     []depth.Level{{102, 1}, {103, 4}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Update(update(104, 104, nil, []depth.Level{{101.5, 7}})); err != nil {
+		t.Fatal(err)
+	}
+
+	bids, asks := s.Book().Top(2)
+	if expected := []depth.Level{{101, 2}, {100, 3}}; !reflect.DeepEqual(bids, expected) {
+		t.Errorf("expected bids %v, got %v", expected, bids)
+	}
+	if expected := []depth.Level{{101.5, 7}, {103, 4}}; !reflect.DeepEqual(asks, expected) {
+		t.Errorf("expected asks %v, got %v", expected, asks)
+	}
+	if s.Book().UpdateID != 104 {
+		t.Errorf("unexpected update id %d", s.Book().UpdateID)
+	}
+}
+
+func TestSyncGap(t *testing.T) {
+	s := depth.NewSync()
+	if err := s.Snapshot(depth.Snapshot{UpdateID: 10, Bids: []depth.Level{{1, 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(update(11, 12, nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(update(14, 15, []depth.Level{{2, 1}}, nil)); !errors.Is(err, depth.ErrGap) {
+		t.Fatalf("expected ErrGap, got %v", err)
+	}
+	if s.Synced() {
+		t.Fatal("synced after a gap")
+	}
+	_ = s.Update(update(16, 16, []depth.Level{{3, 1}}, nil))
+
+	// A snapshot older than the buffered updates is rejected
+	if err := s.Snapshot(depth.Snapshot{UpdateID: 12}); !errors.Is(err, depth.ErrStaleSnapshot) {
+		t.Fatalf("expected ErrStaleSnapshot, got %v", err)
+	}
+	if err := s.Snapshot(depth.Snapshot{UpdateID: 14, Bids: []depth.Level{{1, 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	bids, _ := s.Book().Top(10)
+	if expected := []depth.Level{{3, 1}, {2, 1}, {1, 1}}; !s.Synced() || !reflect.DeepEqual(bids, expected) {
+		t.Errorf("expected bids %v, got %v", expected, bids)
+	}
+}
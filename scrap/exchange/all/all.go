@@ -0,0 +1,11 @@
+// Package all registers every supported exchange.
+package all
+
+import (
+	_ "github.com/dk-open/crypto-zip/scrap/exchange/binance"
+	_ "github.com/dk-open/crypto-zip/scrap/exchange/bitrue"
+	_ "github.com/dk-open/crypto-zip/scrap/exchange/bybit"
+	_ "github.com/dk-open/crypto-zip/scrap/exchange/coinbase"
+	_ "github.com/dk-open/crypto-zip/scrap/exchange/kraken"
+	_ "github.com/dk-open/crypto-zip/scrap/exchange/okx"
+)
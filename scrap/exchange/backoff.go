@@ -0,0 +1,27 @@
+package exchange
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff is an exponential delay range with full jitter.
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// Delay returns the delay before the given retry attempt, starting from zero.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Min
+	for i := 0; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	if d <= b.Min {
+		return d
+	}
+	return b.Min + time.Duration(rand.Int63n(int64(d-b.Min)))
+}
@@ -0,0 +1,68 @@
+package binance_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/binance"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServerTime(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path != "/api/v3/time" {
+			nethttp.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().Add(2*time.Second).UnixMilli())
+	}))
+	defer srv.Close()
+
+	clock := exchange.NewClock(8)
+	s, err := clock.Sample(context.Background(), http.Must(binance.New(exchange.WithBaseURL(srv.URL))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Server time has millisecond resolution and the round trip is local
+	if d := s.Offset - 2*time.Second; d < -50*time.Millisecond || d > 50*time.Millisecond {
+		t.Errorf("expected offset 2s, got %v", s.Offset)
+	}
+}
+
+func TestMarketsSampleClock(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v1/exchangeInfo": {"testdata/exchangeInfo.json"},
+	})
+	clock := exchange.NewClock(8)
+	if _, err := http.Must(binance.New(exchange.WithBaseURL(srv.URL), exchange.WithClock(clock))).Markets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s, ok := clock.Best()
+	if !ok {
+		t.Fatal("expected the server time of exchangeInfo to be sampled")
+	}
+	expected := time.UnixMilli(1729339200123).Sub(s.At.Add(-s.RTT / 2))
+	if d := s.Offset - expected; d < -time.Millisecond || d > time.Millisecond {
+		t.Errorf("expected offset %v, got %v", expected, s.Offset)
+	}
+}
+
+func TestServerTimeCanceled(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := http.Must(binance.NewFutures(exchange.WithHost("https://fapi.binance.com", srv.URL))).ServerTime(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline of the context, got %v", err)
+	}
+}
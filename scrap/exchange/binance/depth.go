@@ -0,0 +1,136 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/depth"
+	"github.com/dk-open/crypto-zip/types"
+	"github.com/goccy/go-json"
+	"golang.org/x/net/websocket"
+	"strings"
+	"time"
+)
+
+const depthPath = "/api/v3/depth"
+const depthQuery = "?limit=1000"
+const depthStreamPath = "/ws/%s@depth@100ms"
+
+type depthSnapshot struct {
+	LastUpdateID uint64                   `json:"lastUpdateId"`
+	Bids         [][2]types.StringToFloat `json:"bids"`
+	Asks         [][2]types.StringToFloat `json:"asks"`
+}
+
+type depthUpdate struct {
+	Event   string                   `json:"e"`
+	FirstID uint64                   `json:"U"`
+	LastID  uint64                   `json:"u"`
+	Bids    [][2]types.StringToFloat `json:"b"`
+	Asks    [][2]types.StringToFloat `json:"a"`
+}
+
+// StreamDepth maintains the order book of the market from the @depth diff stream until the context is done.
+// The f receives the book after every update applied in sync and must not keep it, an error of f stops the stream.
+// The book is resynced from a new snapshot on sequence gaps and the stream reconnects with backoff.
+func (e *Exchange) StreamDepth(ctx context.Context, symbol string, f func(book *depth.Book) error) error {
+	snapshot := func(res *depthSnapshot) error {
+		return e.depthFetcher(ctx, res, http.Query("symbol", strings.ToUpper(symbol)))
+	}
+	var stop error
+	apply := func(book *depth.Book) error {
+		if err := f(book); err != nil {
+			stop = err
+			return err
+		}
+		return nil
+	}
+
+	attempt := 0
+	for ctx.Err() == nil {
+		received, _ := e.streamDepth(ctx, symbol, snapshot, apply)
+		if stop != nil {
+			return stop
+		}
+		if received {
+			attempt = 0
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(e.cfg.Backoff.Delay(attempt)):
+		}
+		attempt++
+	}
+	return ctx.Err()
+}
+
+// streamDepth runs a single connection and reports whether the book has been in sync.
+func (e *Exchange) streamDepth(ctx context.Context, symbol string, snapshot http.FetchFunc[depthSnapshot], f func(book *depth.Book) error) (received bool, err error) {
+	endpoint := e.cfg.StreamEndpoint(streamURL, fmt.Sprintf(depthStreamPath, strings.ToLower(symbol)))
+	wsCfg, err := websocket.NewConfig(endpoint, strings.Replace(endpoint, "ws", "http", 1))
+	if err != nil {
+		return false, err
+	}
+	conn, err := wsCfg.DialContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	connCtx, cancel := context.WithTimeoutCause(ctx, connLifetime, errConnLifetime)
+	defer cancel()
+	go func() {
+		<-connCtx.Done()
+		conn.Close()
+	}()
+
+	bookSync := depth.NewSync()
+	var msg depthUpdate
+	for {
+		if err = conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			return
+		}
+		var data []byte
+		if err = websocket.Message.Receive(conn, &data); err != nil {
+			if cause := context.Cause(connCtx); cause != nil {
+				err = cause
+			}
+			return
+		}
+		msg = depthUpdate{}
+		if err = json.Unmarshal(data, &msg); err != nil {
+			return
+		}
+		if msg.Event != "depthUpdate" {
+			continue
+		}
+		// A gap leaves the update buffered until the next snapshot
+		if err = bookSync.Update(depth.Update{FirstID: msg.FirstID, LastID: msg.LastID, Bids: levels(msg.Bids), Asks: levels(msg.Asks)}); err != nil && !errors.Is(err, depth.ErrGap) {
+			return
+		}
+		if !bookSync.Synced() {
+			var snap depthSnapshot
+			if err = snapshot(&snap); err != nil {
+				return
+			}
+			err = bookSync.Snapshot(depth.Snapshot{UpdateID: snap.LastUpdateID, Bids: levels(snap.Bids), Asks: levels(snap.Asks)})
+			// A stale snapshot is fetched again on the next update
+			if err != nil && !errors.Is(err, depth.ErrStaleSnapshot) && !errors.Is(err, depth.ErrGap) {
+				return
+			}
+		}
+		if bookSync.Synced() {
+			received = true
+			if err = f(bookSync.Book()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func levels(src [][2]types.StringToFloat) []depth.Level {
+	res := make([]depth.Level, len(src))
+	for i, l := range src {
+		res[i] = depth.Level{Price: l[0].Float(), Qty: l[1].Float()}
+	}
+	return res
+}
@@ -0,0 +1,85 @@
+package binance_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/depth"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/binance"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"golang.org/x/net/websocket"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// depthScript is sent by the stream, the update of 108-109 is missed to force a resync.
+var depthScript = []string{
+	`{"e":"depthUpdate","E":1729339200000,"s":"BTCUSDT","U":99,"u":101,"b":[["68010.00000000","9.00000000"]],"a":[]}`,
+	`{"e":"depthUpdate","E":1729339200100,"s":"BTCUSDT","U":102,"u":107,"b":[["68015.00000000","0.00000000"],["68015.80000000","0.70000000"]],"a":[["68016.00000000","1.20000000"]]}`,
+	`{"e":"depthUpdate","E":1729339200300,"s":"BTCUSDT","U":110,"u":112,"b":[["68020.00000000","0.60000000"]],"a":[["68021.00000000","0.00000000"]]}`,
+	`{"e":"depthUpdate","E":1729339200400,"s":"BTCUSDT","U":113,"u":113,"b":[],"a":[["68021.50000000","2.00000000"]]}`,
+}
+
+func TestStreamDepth(t *testing.T) {
+	srv := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		if conn.Request().URL.Path != "/ws/btcusdt@depth@100ms" {
+			return
+		}
+		for _, msg := range depthScript {
+			if err := websocket.Message.Send(conn, msg); err != nil {
+				return
+			}
+		}
+		var data []byte
+		_ = websocket.Message.Receive(conn, &data)
+	}))
+	defer srv.Close()
+	snapshots := exchangetest.Server(t, map[string][]string{
+		"/api/v3/depth": {"testdata/depth1.json", "testdata/depth2.json"},
+	})
+
+	ex := http.Must(binance.New(
+		exchange.WithBaseURL(snapshots.URL),
+		exchange.WithStreamURL(strings.Replace(srv.URL, "http", "ws", 1)),
+	))
+	var updates []uint64
+	var record bytes.Buffer
+	done := errors.New("done")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := ex.StreamDepth(ctx, "BTCUSDT", func(book *depth.Book) error {
+		updates = append(updates, book.UpdateID)
+		if book.UpdateID < 113 {
+			return nil
+		}
+		if err := depth.WriteRecord(&record, book.Record(7, time.UnixMilli(1729339200400), 2), 1e2, 1e8); err != nil {
+			return err
+		}
+		return done
+	})
+	if !errors.Is(err, done) {
+		t.Fatalf("expected the error of the callback, got %v", err)
+	}
+
+	// The first snapshot is 101, the one fetched after the gap 110
+	if expected := []uint64{101, 107, 112, 113}; !reflect.DeepEqual(updates, expected) {
+		t.Fatalf("expected updates %v, got %v", expected, updates)
+	}
+	r := bytes.NewReader(record.Bytes())
+	_, _ = r.ReadByte()
+	res, err := depth.ReadRecord(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []depth.Level{{Price: 68020, Qty: 0.6}, {Price: 68015.5, Qty: 1}}; !reflect.DeepEqual(res.Bids, expected) {
+		t.Errorf("expected bids %v, got %v", expected, res.Bids)
+	}
+	if expected := []depth.Level{{Price: 68021.5, Qty: 2}, {Price: 68022, Qty: 4}}; !reflect.DeepEqual(res.Asks, expected) {
+		t.Errorf("expected asks %v, got %v", expected, res.Asks)
+	}
+}
@@ -0,0 +1,190 @@
+package binance
+
+import (
+	"context"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"strconv"
+	"time"
+)
+
+const FuturesID types.ExchangeID = 8
+const FuturesName = "binance-usdm"
+
+const futuresBaseURL = "https://fapi.binance.com"
+const futuresMarketsPath = "/fapi/v1/exchangeInfo"
+const futuresPricesPath = "/fapi/v1/ticker/bookTicker"
+const premiumIndexPath = "/fapi/v1/premiumIndex"
+const futuresTimePath = "/fapi/v1/time"
+
+// FuturesLimits of the USD-M API, counted separately from the spot API.
+var FuturesLimits = http.Limits{
+	Limits:      []http.Limit{{Weight: 2400, Window: time.Minute, Header: "X-MBX-USED-WEIGHT-1M"}},
+	Costs:       map[string]int{futuresMarketsPath: 1, futuresPricesPath: 5, premiumIndexPath: 10},
+	DefaultCost: 1,
+}
+
+var futuresGovernor = http.NewGovernor(FuturesLimits)
+
+// FundingPrecision is the precision of funding rate series, rates are published with 8 decimals.
+const FundingPrecision = 1e8
+
+type premiumIndex struct {
+	Symbol     string  `json:"symbol"`
+	MarkPrice  float64 `json:"markPrice"`
+	IndexPrice float64 `json:"indexPrice"`
+	// FundingRate is empty for quarterly contracts
+	FundingRate string `json:"lastFundingRate"`
+}
+
+type futuresExchangeInfo struct {
+	ServerTime int64 `json:"serverTime"`
+	Symbols    []struct {
+		Market       string            `json:"symbol"`
+		ContractType string            `json:"contractType"`
+		DeliveryDate int64             `json:"deliveryDate"`
+		Status       string            `json:"status"`
+		BaseAsset    string            `json:"baseAsset"`
+		QuoteAsset   string            `json:"quoteAsset"`
+		Filters      []exchange.Filter `json:"filters"`
+	} `json:"symbols"`
+}
+
+func init() {
+	exchange.Register(FuturesName, FuturesID, func(opts ...exchange.Option) (exchange.Exchange, error) {
+		e, err := NewFutures(opts...)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	})
+}
+
+// Futures is the Binance USD-M futures exchange of perpetual and quarterly contracts.
+type Futures struct {
+	cfg            exchange.Config
+	marketsFetcher http.CallFetch[futuresExchangeInfo]
+	priceFetcher   http.CallIterate[bookPrices]
+	premiumFetcher http.CallIterate[premiumIndex]
+	timeFetcher    http.CallFetch[serverTime]
+}
+
+// NewFutures creates the USD-M exchange, failing on a malformed base url of the options.
+func NewFutures(opts ...exchange.Option) (*Futures, error) {
+	cfg := exchange.NewConfig(opts...)
+	cfg.Client = cfg.Govern(futuresGovernor)
+	res := &Futures{cfg: cfg}
+	marketsTemplate, err := http.NewTemplate("GET", cfg.URL(futuresBaseURL, futuresMarketsPath), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.marketsFetcher = http.TemplateFetcher[futuresExchangeInfo](cfg.Client, marketsTemplate)
+	pricesTemplate, err := http.NewTemplate("GET", cfg.URL(futuresBaseURL, futuresPricesPath), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.priceFetcher = http.TemplateIterator[bookPrices](cfg.Client, pricesTemplate, 1)
+	premiumTemplate, err := http.NewTemplate("GET", cfg.URL(futuresBaseURL, premiumIndexPath), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.premiumFetcher = http.TemplateIterator[premiumIndex](cfg.Client, premiumTemplate, 1)
+	if res.timeFetcher, err = newTimeFetcher(cfg, cfg.URL(futuresBaseURL, futuresTimePath)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (e *Futures) ID() types.ExchangeID {
+	return FuturesID
+}
+
+func (e *Futures) Name() string {
+	return FuturesName
+}
+
+func (e *Futures) Prices(buf scrap.IPriceWriter) error {
+	return writeBookPrices(e.priceFetcher, buf)
+}
+
+// ServerTime returns the time of the exchange servers.
+func (e *Futures) ServerTime(ctx context.Context) (time.Time, error) {
+	return fetchServerTime(ctx, e.timeFetcher)
+}
+
+// Markets returns perpetual and quarterly contracts of any status, select the tradable ones with exchange.Trading.
+func (e *Futures) Markets(ctx context.Context) (res []exchange.Market, err error) {
+	var info futuresExchangeInfo
+	sent := time.Now()
+	if err = e.marketsFetcher(ctx, &info); err != nil {
+		return nil, err
+	}
+	sampleClock(e.cfg.Clock, sent, info.ServerTime)
+
+	for _, s := range info.Symbols {
+		m := exchange.Market{
+			Name:         s.Market,
+			Base:         s.BaseAsset,
+			Quote:        s.QuoteAsset,
+			Precision:    1,
+			ContractSize: 1,
+			Status:       exchange.ParseStatus(s.Status),
+		}
+		exchange.ParseFilters(s.Filters).Apply(&m)
+		switch s.ContractType {
+		case "PERPETUAL":
+			m.Type = exchange.Perpetual
+		case "CURRENT_QUARTER", "NEXT_QUARTER":
+			m.Type = exchange.Future
+			m.Expiry = time.UnixMilli(s.DeliveryDate).UTC()
+		default:
+			continue
+		}
+		res = append(res, m)
+	}
+	return
+}
+
+// PremiumIndex writes mark prices, index prices and funding rates of a single premium index request.
+// Any of the writers may be nil. Funding rates are written for perpetual contracts only.
+func (e *Futures) PremiumIndex(mark, index, funding scrap.IValueWriter) (err error) {
+	return e.premiumFetcher(context.Background(), func(v premiumIndex) error {
+		if mark != nil && v.MarkPrice > 0. {
+			if err = mark.Write(v.Symbol, v.MarkPrice); err != nil {
+				return err
+			}
+		}
+		if index != nil && v.IndexPrice > 0. {
+			if err = index.Write(v.Symbol, v.IndexPrice); err != nil {
+				return err
+			}
+		}
+		if funding != nil && v.FundingRate != "" {
+			rate, pErr := strconv.ParseFloat(v.FundingRate, 64)
+			if pErr != nil {
+				return pErr
+			}
+			if err = funding.Write(v.Symbol, rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkPrices is the producer of the mark price series.
+func (e *Futures) MarkPrices(w scrap.IValueWriter) error {
+	return e.PremiumIndex(w, nil, nil)
+}
+
+// IndexPrices is the producer of the index price series.
+func (e *Futures) IndexPrices(w scrap.IValueWriter) error {
+	return e.PremiumIndex(nil, w, nil)
+}
+
+// FundingRates is the producer of the funding rate series.
+func (e *Futures) FundingRates(w scrap.IValueWriter) error {
+	return e.PremiumIndex(nil, nil, w)
+}
@@ -0,0 +1,92 @@
+package binance_test
+
+import (
+	"context"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/binance"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type valuesRecorder map[string]float64
+
+func (r valuesRecorder) Write(name string, value float64) error {
+	r[name] = value
+	return nil
+}
+
+func testFutures(t *testing.T) *binance.Futures {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/fapi/v1/exchangeInfo":      {"testdata/fapiExchangeInfo.json"},
+		"/fapi/v1/ticker/bookTicker": {"testdata/fapiBookTicker.json"},
+		"/fapi/v1/premiumIndex":      {"testdata/premiumIndex.json"},
+	})
+	return http.Must(binance.NewFutures(exchange.WithBaseURL(srv.URL)))
+}
+
+func TestFuturesMarkets(t *testing.T) {
+	markets, err := testFutures(t).Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lot := exchange.LotSize{MinQty: 0.001, MaxQty: 1000, StepSize: 0.001}
+	priceFilter := func(tick float64) exchange.PriceFilter {
+		return exchange.PriceFilter{MinPrice: 556.8, MaxPrice: 4529764, TickSize: tick}
+	}
+	expected := []exchange.Market{
+		{Name: "BTCUSDT", Base: "BTC", Quote: "USDT", Precision: 1e1, Type: exchange.Perpetual, ContractSize: 1,
+			PriceFilter: priceFilter(0.1), LotSize: lot, MinNotional: 100, QuantityPrecision: 1e3},
+		{Name: "ETHUSDT", Base: "ETH", Quote: "USDT", Precision: 1e2, Type: exchange.Perpetual, ContractSize: 1,
+			PriceFilter: priceFilter(0.01), LotSize: lot, MinNotional: 100, QuantityPrecision: 1e3},
+		{Name: "BTCUSDT_241227", Base: "BTC", Quote: "USDT", Precision: 1e1, Type: exchange.Future, ContractSize: 1,
+			Expiry:      time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC),
+			PriceFilter: priceFilter(0.1), LotSize: lot, MinNotional: 100, QuantityPrecision: 1e3},
+		{Name: "1000PEPEUSDT", Base: "1000PEPE", Quote: "USDT", Precision: 1e7, Type: exchange.Perpetual, ContractSize: 1,
+			PriceFilter: priceFilter(0.0000001), LotSize: lot, MinNotional: 100, QuantityPrecision: 1e3},
+		{Name: "ETHUSDT_240927", Base: "ETH", Quote: "USDT", Precision: 1e2, Type: exchange.Future, ContractSize: 1,
+			Expiry: time.Date(2024, 9, 27, 8, 0, 0, 0, time.UTC), Status: exchange.StatusDelisted,
+			PriceFilter: priceFilter(0.01), LotSize: lot, MinNotional: 100, QuantityPrecision: 1e3},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+}
+
+func TestFuturesPrices(t *testing.T) {
+	res := exchangetest.Prices{}
+	if err := testFutures(t).Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected := exchangetest.Prices{
+		"BTCUSDT":        {68035.1, 68035.2},
+		"ETHUSDT":        {2602.1, 2602.11},
+		"BTCUSDT_241227": {69120.4, 69120.6},
+		"1000PEPEUSDT":   {0.0100089, 0.010009},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestPremiumIndex(t *testing.T) {
+	mark, index, funding := valuesRecorder{}, valuesRecorder{}, valuesRecorder{}
+	if err := testFutures(t).PremiumIndex(mark, index, funding); err != nil {
+		t.Fatal(err)
+	}
+	expectedMark := valuesRecorder{"BTCUSDT": 68035.2, "ETHUSDT": 2602.11, "BTCUSDT_241227": 69120.5, "1000PEPEUSDT": 0.010009}
+	if !reflect.DeepEqual(mark, expectedMark) {
+		t.Errorf("expected mark prices %v, got %v", expectedMark, mark)
+	}
+	expectedIndex := valuesRecorder{"BTCUSDT": 68011.35311111, "ETHUSDT": 2601.9012766, "BTCUSDT_241227": 68011.35311111, "1000PEPEUSDT": 0.0100071}
+	if !reflect.DeepEqual(index, expectedIndex) {
+		t.Errorf("expected index prices %v, got %v", expectedIndex, index)
+	}
+	// Quarterly contracts have no funding
+	expectedFunding := valuesRecorder{"BTCUSDT": 0.0001, "ETHUSDT": -0.00002341, "1000PEPEUSDT": 0}
+	if !reflect.DeepEqual(funding, expectedFunding) {
+		t.Errorf("expected funding rates %v, got %v", expectedFunding, funding)
+	}
+}
@@ -2,11 +2,12 @@ package binance
 
 import (
 	"context"
+	"errors"
 	"github.com/dk-open/crypto-zip/http"
 	"github.com/dk-open/crypto-zip/scrap"
 	"github.com/dk-open/crypto-zip/scrap/exchange"
-	"math"
-	"strconv"
+	"github.com/dk-open/crypto-zip/types"
+	"time"
 )
 
 type bookPrices struct {
@@ -15,78 +16,162 @@ type bookPrices struct {
 	Ask    float64 `json:"askPrice"`
 }
 
+type serverTime struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
 type exchangeInfo struct {
 	ServerTime int64 `json:"serverTime"`
 	Symbols    []struct {
-		Market              string                   `json:"symbol"`
-		Status              string                   `json:"status"`
-		BaseAsset           string                   `json:"baseAsset"`
-		BaseAssetPrecision  int                      `json:"baseAssetPrecision"`
-		QuoteAsset          string                   `json:"quoteAsset"`
-		QuoteAssetPrecision int                      `json:"quoteAssetPrecision"`
-		OrderTypes          []string                 `json:"orderTypes"`
-		Filters             []map[string]interface{} `json:"filters"`
-		Permissions         []string                 `json:"permissions"`
+		Market              string            `json:"symbol"`
+		Status              string            `json:"status"`
+		BaseAsset           string            `json:"baseAsset"`
+		BaseAssetPrecision  int               `json:"baseAssetPrecision"`
+		QuoteAsset          string            `json:"quoteAsset"`
+		QuoteAssetPrecision int               `json:"quoteAssetPrecision"`
+		OrderTypes          []string          `json:"orderTypes"`
+		Filters             []exchange.Filter `json:"filters"`
+		Permissions         []string          `json:"permissions"`
 	} `json:"symbols"`
 }
 
-var marketsFetcher = http.Fetcher[exchangeInfo]("GET", "https://api.binance.com/api/v1/exchangeInfo")
-var priceFetcher = http.Iterator[bookPrices]("GET", "https://api.binance.com/api/v3/ticker/bookTicker", 1)
+const ID types.ExchangeID = 1
+const Name = "binance"
 
-func Prices(buf scrap.IPriceWriter) (err error) {
-	return priceFetcher(func(v bookPrices) error {
-		if v.Bid > 0. && v.Ask > 0. {
-			if err = buf.Write(v.Symbol, v.Bid, v.Ask); err != nil {
-				return err
-			}
+const baseURL = "https://api.binance.com"
+const marketsPath = "/api/v1/exchangeInfo"
+const pricesPath = "/api/v3/ticker/bookTicker"
+const timePath = "/api/v3/time"
+
+// Limits of the spot API. Weights are counted per IP in minute windows and reported by the server.
+var Limits = http.Limits{
+	Limits:      []http.Limit{{Weight: 6000, Window: time.Minute, Header: "X-MBX-USED-WEIGHT-1M"}},
+	Costs:       map[string]int{marketsPath: 20, pricesPath: 4, depthPath: 50},
+	DefaultCost: 1,
+}
+
+var governor = http.NewGovernor(Limits)
+
+// parseErrors reads error bodies like {"code":-1121,"msg":"Invalid symbol."}, shared by spot and futures.
+var parseErrors = http.WithErrorParser(http.JSONErrorParser("code", "msg"))
+
+func init() {
+	exchange.Register(Name, ID, func(opts ...exchange.Option) (exchange.Exchange, error) {
+		e, err := New(opts...)
+		if err != nil {
+			return nil, err
 		}
-		return nil
+		return e, nil
 	})
 }
 
-func Markets(ctx context.Context) (res []exchange.Market, err error) {
+type Exchange struct {
+	cfg            exchange.Config
+	marketsFetcher http.CallFetch[exchangeInfo]
+	priceFetcher   http.CallIterate[bookPrices]
+	timeFetcher    http.CallFetch[serverTime]
+	depthFetcher   http.CallFetch[depthSnapshot]
+}
+
+// New creates the spot exchange, failing on a malformed base url of the options.
+func New(opts ...exchange.Option) (*Exchange, error) {
+	cfg := exchange.NewConfig(opts...)
+	cfg.Client = cfg.Govern(governor)
+	res := &Exchange{cfg: cfg}
+	marketsTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, marketsPath), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.marketsFetcher = http.TemplateFetcher[exchangeInfo](cfg.Client, marketsTemplate)
+	pricesTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, pricesPath), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.priceFetcher = http.TemplateIterator[bookPrices](cfg.Client, pricesTemplate, 1)
+	if res.timeFetcher, err = newTimeFetcher(cfg, cfg.URL(baseURL, timePath)); err != nil {
+		return nil, err
+	}
+	depthTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, depthPath+depthQuery), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.depthFetcher = http.TemplateFetcher[depthSnapshot](cfg.Client, depthTemplate)
+	return res, nil
+}
+
+func (e *Exchange) ID() types.ExchangeID {
+	return ID
+}
+
+func (e *Exchange) Name() string {
+	return Name
+}
+
+func (e *Exchange) Prices(buf scrap.IPriceWriter) error {
+	return writeBookPrices(e.priceFetcher, buf)
+}
+
+// ServerTime returns the time of the exchange servers.
+func (e *Exchange) ServerTime(ctx context.Context) (time.Time, error) {
+	return fetchServerTime(ctx, e.timeFetcher)
+}
+
+// Markets returns symbols of any status, select the tradable ones with exchange.Trading.
+func (e *Exchange) Markets(ctx context.Context) (res []exchange.Market, err error) {
 	var info exchangeInfo
-	if err = marketsFetcher(&info); err != nil {
+	sent := time.Now()
+	if err = e.marketsFetcher(ctx, &info); err != nil {
 		return nil, err
 	}
+	sampleClock(e.cfg.Clock, sent, info.ServerTime)
 
 	for _, pair := range info.Symbols {
-		if pair.Status == "TRADING" {
-			res = append(res, exchange.Market{
-				Name:      pair.Market,
-				Base:      pair.BaseAsset,
-				Quote:     pair.QuoteAsset,
-				Precision: math.Pow(10, float64(getPricePrecision(pair.Filters))),
-			})
+		m := exchange.Market{
+			Name:      pair.Market,
+			Base:      pair.BaseAsset,
+			Quote:     pair.QuoteAsset,
+			Precision: 1,
+			Status:    exchange.ParseStatus(pair.Status),
 		}
+		exchange.ParseFilters(pair.Filters).Apply(&m)
+		res = append(res, m)
 	}
 	return
 }
 
-func getPricePrecision(filters []map[string]interface{}) (res uint8) {
-	for _, v := range filters {
-		if v["filterType"] == "PRICE_FILTER" {
-			if resPrec, ok := v["tickSize"]; ok {
-				if precStr, sok := resPrec.(string); sok {
-					f, _ := strconv.ParseFloat(precStr, 64)
-					return DigitsAfterDot(f)
-				}
-			}
+// writeBookPrices writes book tickers with both sides quoted, shared by spot and futures.
+func writeBookPrices(fetcher http.CallIterate[bookPrices], buf scrap.IPriceWriter) error {
+	return fetcher(context.Background(), func(v bookPrices) error {
+		if v.Bid > 0. && v.Ask > 0. {
+			return buf.Write(v.Symbol, v.Bid, v.Ask)
 		}
-	}
-	return
+		return nil
+	})
 }
 
-func DigitsAfterDot(f float64) uint8 {
-	// Subtract integer part to get the fraction
-	fraction := f - math.Floor(f)
+// newTimeFetcher fetches the server time with the context of the call, shared by spot and futures.
+func newTimeFetcher(cfg exchange.Config, url string) (http.CallFetch[serverTime], error) {
+	t, err := http.NewTemplate("GET", url, parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	return http.TemplateFetcher[serverTime](cfg.Client, t), nil
+}
 
-	var count uint8 = 0
-	// While fraction is not an integer
-	for fraction != math.Floor(fraction) {
-		fraction *= 10
-		count++
+func fetchServerTime(ctx context.Context, fetcher http.CallFetch[serverTime]) (time.Time, error) {
+	var res serverTime
+	if err := fetcher(ctx, &res); err != nil {
+		return time.Time{}, err
+	}
+	if res.ServerTime <= 0 {
+		return time.Time{}, errors.New("binance: missing server time")
 	}
+	return time.UnixMilli(res.ServerTime), nil
+}
 
-	return count
+// sampleClock records the server time of a response to a request sent at the given time.
+func sampleClock(clock *exchange.Clock, sent time.Time, serverTime int64) {
+	if clock != nil && serverTime > 0 {
+		clock.Add(sent, time.UnixMilli(serverTime), time.Now())
+	}
 }
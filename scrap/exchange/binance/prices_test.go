@@ -0,0 +1,124 @@
+package binance_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/binance"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	nethttp "net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func testExchange(t *testing.T) *binance.Exchange {
+	return exchangetest.Exchange(t, map[string][]string{
+		"/api/v1/exchangeInfo":      {"testdata/exchangeInfo.json"},
+		"/api/v3/ticker/bookTicker": {"testdata/bookTicker.json"},
+	}, binance.New)
+}
+
+func TestMarkets(t *testing.T) {
+	markets, err := testExchange(t).Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.Market{
+		{Name: "ETHBTC", Base: "ETH", Quote: "BTC", Precision: 1e5,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.00001, MaxPrice: 922327, TickSize: 0.00001},
+			LotSize:     exchange.LotSize{MinQty: 0.0001, MaxQty: 100000, StepSize: 0.0001},
+			MinNotional: 0.0001, QuantityPrecision: 1e4},
+		{Name: "BTCUSDT", Base: "BTC", Quote: "USDT", Precision: 1e2,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.01, MaxPrice: 1000000, TickSize: 0.01},
+			LotSize:     exchange.LotSize{MinQty: 0.00001, MaxQty: 9000, StepSize: 0.00001},
+			MinNotional: 5, QuantityPrecision: 1e5},
+		{Name: "XRPUSDT", Base: "XRP", Quote: "USDT", Precision: 1e4,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.0001, MaxPrice: 10000, TickSize: 0.0001},
+			LotSize:     exchange.LotSize{MinQty: 1, MaxQty: 9222449, StepSize: 1},
+			MinNotional: 5, QuantityPrecision: 1},
+		{Name: "SHIBUSDT", Base: "SHIB", Quote: "USDT", Precision: 1e8,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.00000001, MaxPrice: 1, TickSize: 0.00000001},
+			LotSize:     exchange.LotSize{MinQty: 1, MaxQty: 46116860414, StepSize: 1},
+			MinNotional: 5, QuantityPrecision: 1},
+		{Name: "LUNAUSDT", Base: "LUNA", Quote: "USDT", Precision: 1e4, Status: exchange.StatusHalted,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.0001, MaxPrice: 1000, TickSize: 0.0001},
+			LotSize:     exchange.LotSize{MinQty: 0.01, MaxQty: 913205152, StepSize: 0.01},
+			MinNotional: 5, QuantityPrecision: 1e2},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+}
+
+func TestPrices(t *testing.T) {
+	res := exchangetest.Prices{}
+	if err := testExchange(t).Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected := exchangetest.Prices{
+		"ETHBTC":   {0.03606, 0.03607},
+		"BTCUSDT":  {68012.01, 68012.02},
+		"XRPUSDT":  {0.5421, 0.5422},
+		"SHIBUSDT": {0.00001812, 0.00001813},
+		"BNBBTC":   {0.008452, 0.008453},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestMarketsError(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+	}))
+	defer srv.Close()
+
+	_, err := http.Must(binance.New(exchange.WithBaseURL(srv.URL))).Markets(context.Background())
+	var se *http.StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if code, _ := se.IntCode(); code != -1121 || se.Message != "Invalid symbol." {
+		t.Errorf("unexpected error %+v", se)
+	}
+}
+
+func TestInvalidBaseURL(t *testing.T) {
+	if _, err := binance.New(exchange.WithBaseURL("http://[::1")); err == nil {
+		t.Error("New: expected error")
+	}
+	if _, err := binance.NewFutures(exchange.WithHost("https://fapi.binance.com", "http://[::1")); err == nil {
+		t.Error("NewFutures: expected error")
+	}
+	if _, err := exchange.New(binance.Name, exchange.WithBaseURL("http://[::1")); err == nil {
+		t.Error("exchange.New: expected error")
+	}
+}
+
+func TestMarketsStatusChanges(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v1/exchangeInfo": {"testdata/exchangeInfo.json", "testdata/exchangeInfo2.json"},
+	})
+	e := http.Must(binance.New(exchange.WithBaseURL(srv.URL)))
+	prev, err := e.Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := e.Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.StatusChange{
+		{Name: "XRPUSDT", From: exchange.StatusTrading, To: exchange.StatusHalted},
+		{Name: "LUNAUSDT", From: exchange.StatusHalted, To: exchange.StatusTrading},
+	}
+	if res := exchange.StatusChanges(prev, next); !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+	if trading := exchange.Trading(next); len(trading) != 4 {
+		t.Errorf("expected 4 trading markets, got %v", trading)
+	}
+}
@@ -0,0 +1,169 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"github.com/goccy/go-json"
+	"golang.org/x/net/websocket"
+	"strings"
+	"sync"
+	"time"
+)
+
+const streamURL = "wss://stream.binance.com:9443"
+const streamPath = "/stream"
+
+const (
+	// maxStreamsPerConn is the limit of streams a single connection can subscribe to.
+	maxStreamsPerConn = 1024
+	// subscribeBatch is the number of streams per SUBSCRIBE message.
+	subscribeBatch = 200
+	// subscribeInterval keeps control messages below the limit of 5 per second.
+	subscribeInterval = 250 * time.Millisecond
+	// connLifetime reconnects ahead of the forced disconnect after 24 hours.
+	connLifetime = 23*time.Hour + 50*time.Minute
+	// readTimeout detects dead connections. Binance pings every 20 seconds and
+	// pongs are replied by the websocket frame handler while reading.
+	readTimeout = time.Minute
+)
+
+type subscribeRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+type streamMessage struct {
+	Stream string `json:"stream"`
+	Data   struct {
+		Symbol string              `json:"s"`
+		Bid    types.StringToFloat `json:"b"`
+		BidQty types.StringToFloat `json:"B"`
+		Ask    types.StringToFloat `json:"a"`
+		AskQty types.StringToFloat `json:"A"`
+	} `json:"data"`
+}
+
+var errConnLifetime = errors.New("binance: connection lifetime reached")
+
+// Stream writes @bookTicker updates of the markets until the context is done.
+// Markets are split into connections of up to 1024 streams which reconnect with backoff.
+func (e *Exchange) Stream(ctx context.Context, markets []exchange.Market, w scrap.IPriceWriter) error {
+	sw := &syncWriter{w: w}
+	var wg sync.WaitGroup
+	for start := 0; start < len(markets); start += maxStreamsPerConn {
+		streams := make([]string, 0, maxStreamsPerConn)
+		for _, m := range markets[start:min(start+maxStreamsPerConn, len(markets))] {
+			streams = append(streams, strings.ToLower(m.Name)+"@bookTicker")
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.keepStreaming(ctx, streams, sw)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// keepStreaming reconnects the connection until the context is done.
+func (e *Exchange) keepStreaming(ctx context.Context, streams []string, w scrap.IPriceWriter) {
+	attempt := 0
+	for ctx.Err() == nil {
+		received, err := e.stream(ctx, streams, w)
+		if received {
+			attempt = 0
+		}
+		if errors.Is(err, errConnLifetime) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(e.cfg.Backoff.Delay(attempt)):
+		}
+		attempt++
+	}
+}
+
+// stream runs a single connection and reports whether any price has been received.
+func (e *Exchange) stream(ctx context.Context, streams []string, w scrap.IPriceWriter) (received bool, err error) {
+	endpoint := e.cfg.StreamEndpoint(streamURL, streamPath)
+	wsCfg, err := websocket.NewConfig(endpoint, strings.Replace(endpoint, "ws", "http", 1))
+	if err != nil {
+		return false, err
+	}
+	conn, err := wsCfg.DialContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	connCtx, cancel := context.WithTimeoutCause(ctx, connLifetime, errConnLifetime)
+	defer cancel()
+	go func() {
+		<-connCtx.Done()
+		conn.Close()
+	}()
+
+	go e.subscribe(connCtx, conn, streams)
+
+	var msg streamMessage
+	for {
+		if err = conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			break
+		}
+		var data []byte
+		if err = websocket.Message.Receive(conn, &data); err != nil {
+			break
+		}
+		msg.Data.Symbol = ""
+		if err = json.Unmarshal(data, &msg); err != nil {
+			break
+		}
+		// Subscription results have no data
+		if msg.Data.Symbol == "" {
+			continue
+		}
+		if msg.Data.Bid > 0. && msg.Data.Ask > 0. {
+			if err = w.Write(msg.Data.Symbol, msg.Data.Bid.Float(), msg.Data.Ask.Float()); err != nil {
+				break
+			}
+			received = true
+		}
+	}
+	if cause := context.Cause(connCtx); cause != nil {
+		return received, cause
+	}
+	return received, err
+}
+
+// subscribe sends SUBSCRIBE messages in batches respecting the incoming messages limit.
+func (e *Exchange) subscribe(ctx context.Context, conn *websocket.Conn, streams []string) {
+	for id := 1; len(streams) > 0; id++ {
+		batch := streams[:min(subscribeBatch, len(streams))]
+		streams = streams[len(batch):]
+		if err := websocket.JSON.Send(conn, subscribeRequest{Method: "SUBSCRIBE", Params: batch, ID: id}); err != nil {
+			conn.Close()
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(subscribeInterval):
+		}
+	}
+}
+
+// syncWriter serializes writes of concurrent connections.
+type syncWriter struct {
+	mu sync.Mutex
+	w  scrap.IPriceWriter
+}
+
+func (s *syncWriter) Write(name string, bid, ask float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(name, bid, ask)
+}
@@ -0,0 +1,134 @@
+package binance_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/binance"
+	"github.com/dk-open/crypto-zip/types"
+	"golang.org/x/net/websocket"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type subscribeRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+// streamServer stands in for the Binance combined streams endpoint. It drops the first
+// connection once prices are sent, the way the 24h forced disconnect does.
+type streamServer struct {
+	mu          sync.Mutex
+	connections int
+	batches     [][]string
+}
+
+func (s *streamServer) handle(conn *websocket.Conn) {
+	s.mu.Lock()
+	s.connections++
+	n := s.connections
+	s.mu.Unlock()
+
+	subscribed := map[string]bool{}
+	for len(subscribed) < 250 {
+		var req subscribeRequest
+		if err := websocket.JSON.Receive(conn, &req); err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.batches = append(s.batches, req.Params)
+		s.mu.Unlock()
+		for _, p := range req.Params {
+			subscribed[p] = true
+		}
+		if err := websocket.Message.Send(conn, fmt.Sprintf(`{"result":null,"id":%d}`, req.ID)); err != nil {
+			return
+		}
+	}
+	if !subscribed["btcusdt@bookTicker"] {
+		return
+	}
+
+	bid := fmt.Sprintf("%d.01000000", 68000+n)
+	msg := `{"stream":"btcusdt@bookTicker","data":{"u":400900217,"s":"BTCUSDT","b":"` + bid + `","B":"31.21000000","a":"68100.00000000","A":"40.66000000"}}`
+	if err := websocket.Message.Send(conn, msg); err != nil {
+		return
+	}
+	if n > 1 {
+		// Keep the connection until the client goes away
+		var data []byte
+		_ = websocket.Message.Receive(conn, &data)
+	}
+}
+
+type streamRecorder struct {
+	mu     sync.Mutex
+	prices map[string]types.Price
+	notify chan struct{}
+}
+
+func (r *streamRecorder) Write(name string, bid, ask float64) error {
+	r.mu.Lock()
+	r.prices[name] = types.Price{bid, ask}
+	r.mu.Unlock()
+	r.notify <- struct{}{}
+	return nil
+}
+
+func TestStreamReconnects(t *testing.T) {
+	server := &streamServer{}
+	srv := httptest.NewServer(websocket.Handler(server.handle))
+	defer srv.Close()
+
+	markets := []exchange.Market{{Name: "BTCUSDT"}}
+	for i := 1; i < 250; i++ {
+		markets = append(markets, exchange.Market{Name: fmt.Sprintf("M%dUSDT", i)})
+	}
+
+	ex := http.Must(binance.New(
+		exchange.WithStreamURL(strings.Replace(srv.URL, "http", "ws", 1)),
+		exchange.WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+	))
+	recorder := &streamRecorder{prices: map[string]types.Price{}, notify: make(chan struct{}, 10)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ex.Stream(ctx, markets, recorder)
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-recorder.notify:
+		case <-timeout:
+			t.Fatalf("prices of the reconnected stream not received: %v", recorder.prices)
+		}
+		recorder.mu.Lock()
+		price := recorder.prices["BTCUSDT"]
+		recorder.mu.Unlock()
+		if price == (types.Price{68002.01, 68100}) {
+			break
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.connections != 2 {
+		t.Errorf("expected 2 connections, got %d", server.connections)
+	}
+	if len(server.batches) != 4 || len(server.batches[0]) != 200 || len(server.batches[1]) != 50 {
+		t.Errorf("unexpected subscription batches of %d messages", len(server.batches))
+	}
+}
@@ -2,11 +2,12 @@ package bitrue
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"github.com/dk-open/crypto-zip/http"
 	"github.com/dk-open/crypto-zip/scrap"
 	"github.com/dk-open/crypto-zip/scrap/exchange"
-	"math"
+	"github.com/dk-open/crypto-zip/types"
+	"time"
 )
 
 type bookPrices struct {
@@ -17,20 +18,92 @@ type bookPrices struct {
 
 type marketsData struct {
 	Symbols []struct {
-		Symbol    string `json:"symbol"`
-		Status    string `json:"status"`
-		Sell      string `json:"baseAsset"`
-		Buy       string `json:"quoteAsset"`
-		Precision uint8  `json:"quotePrecision"`
+		Symbol  string            `json:"symbol"`
+		Status  string            `json:"status"`
+		Sell    string            `json:"baseAsset"`
+		Buy     string            `json:"quoteAsset"`
+		Filters []exchange.Filter `json:"filters"`
 	} `json:"symbols"`
 }
 
-var marketsFetcher = http.Fetcher[marketsData]("GET", "https://openapi.bitrue.com/api/v1/exchangeInfo")
-var priceFetcher = http.Iterator[bookPrices]("GET", "https://bitrue.com/api/v1/ticker/24hr", 1)
+const ID types.ExchangeID = 2
+const Name = "bitrue"
 
-func Prices(buf scrap.IPriceWriter) (err error) {
-	fmt.Println("Get Prices")
-	return priceFetcher(func(v bookPrices) error {
+// MarketsBaseURL and PricesBaseURL are the hosts of the API, each can be overridden by exchange.WithHost.
+const (
+	MarketsBaseURL = "https://openapi.bitrue.com"
+	PricesBaseURL  = "https://bitrue.com"
+)
+
+const marketsPath = "/api/v1/exchangeInfo"
+const pricesPath = "/api/v1/ticker/24hr"
+const timePath = "/api/v1/time"
+
+// Limits of the API, weights are counted per IP in minute windows.
+var Limits = http.Limits{
+	Limits:      []http.Limit{{Weight: 1200, Window: time.Minute}},
+	Costs:       map[string]int{marketsPath: 1, pricesPath: 40},
+	DefaultCost: 1,
+}
+
+var governor = http.NewGovernor(Limits)
+
+// parseErrors reads error bodies like {"code":-1121,"msg":"Invalid symbol."}
+var parseErrors = http.WithErrorParser(http.JSONErrorParser("code", "msg"))
+
+func init() {
+	exchange.Register(Name, ID, func(opts ...exchange.Option) (exchange.Exchange, error) {
+		e, err := New(opts...)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	})
+}
+
+type Exchange struct {
+	marketsFetcher http.CallFetch[marketsData]
+	priceFetcher   http.CallIterate[bookPrices]
+	timeFetcher    http.CallFetch[serverTime]
+}
+
+type serverTime struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
+// New creates the exchange, failing on a malformed base url of the options.
+func New(opts ...exchange.Option) (*Exchange, error) {
+	cfg := exchange.NewConfig(opts...)
+	cfg.Client = cfg.Govern(governor)
+	res := &Exchange{}
+	marketsTemplate, err := http.NewTemplate("GET", cfg.URL(MarketsBaseURL, marketsPath), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.marketsFetcher = http.TemplateFetcher[marketsData](cfg.Client, marketsTemplate)
+	pricesTemplate, err := http.NewTemplate("GET", cfg.URL(PricesBaseURL, pricesPath), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.priceFetcher = http.TemplateIterator[bookPrices](cfg.Client, pricesTemplate, 1)
+	timeTemplate, err := http.NewTemplate("GET", cfg.URL(MarketsBaseURL, timePath), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.timeFetcher = http.TemplateFetcher[serverTime](cfg.Client, timeTemplate)
+	return res, nil
+}
+
+func (e *Exchange) ID() types.ExchangeID {
+	return ID
+}
+
+func (e *Exchange) Name() string {
+	return Name
+}
+
+func (e *Exchange) Prices(buf scrap.IPriceWriter) (err error) {
+	return e.priceFetcher(context.Background(), func(v bookPrices) error {
 		if v.Bid > 0. && v.Ask > 0. {
 			if err = buf.Write(v.Symbol, v.Bid, v.Ask); err != nil {
 				return err
@@ -40,21 +113,36 @@ func Prices(buf scrap.IPriceWriter) (err error) {
 	})
 }
 
-func Markets(ctx context.Context) (res []exchange.Market, err error) {
+// ServerTime returns the time of the exchange servers.
+func (e *Exchange) ServerTime(ctx context.Context) (time.Time, error) {
+	var res serverTime
+	if err := e.timeFetcher(ctx, &res); err != nil {
+		return time.Time{}, err
+	}
+	if res.ServerTime <= 0 {
+		return time.Time{}, errors.New("bitrue: missing server time")
+	}
+	return time.UnixMilli(res.ServerTime), nil
+}
+
+// Markets returns symbols of any status, select the tradable ones with exchange.Trading.
+func (e *Exchange) Markets(ctx context.Context) (res []exchange.Market, err error) {
 	var info marketsData
-	if err = marketsFetcher(&info); err != nil {
+	if err = e.marketsFetcher(ctx, &info); err != nil {
 		return nil, err
 	}
 
 	for _, pair := range info.Symbols {
-		if pair.Status == "TRADING" {
-			res = append(res, exchange.Market{
-				Name:      pair.Symbol,
-				Base:      pair.Sell,
-				Quote:     pair.Buy,
-				Precision: math.Pow(10, float64(pair.Precision)),
-			})
+		m := exchange.Market{
+			Name:      pair.Symbol,
+			Base:      pair.Sell,
+			Quote:     pair.Buy,
+			Precision: 1,
+			Status:    exchange.ParseStatus(pair.Status),
 		}
+		// quotePrecision is the precision of the asset, prices move by the tick of the price filter
+		exchange.ParseFilters(pair.Filters).Apply(&m)
+		res = append(res, m)
 	}
 	return
 }
@@ -0,0 +1,129 @@
+package bitrue_test
+
+import (
+	"context"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/bitrue"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testExchange(t *testing.T) *bitrue.Exchange {
+	return exchangetest.Exchange(t, map[string][]string{
+		"/api/v1/exchangeInfo": {"testdata/exchangeInfo.json"},
+		"/api/v1/ticker/24hr":  {"testdata/ticker24hr.json"},
+	}, bitrue.New)
+}
+
+func TestMarkets(t *testing.T) {
+	markets, err := testExchange(t).Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.Market{
+		{Name: "BTCUSDT", Base: "btc", Quote: "usdt", Precision: 1e2,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.01, MaxPrice: 1000000, TickSize: 0.01},
+			LotSize:     exchange.LotSize{MinQty: 0.00001, MaxQty: 1000, StepSize: 0.00001},
+			MinNotional: 5, QuantityPrecision: 1e5},
+		{Name: "ETHUSDT", Base: "eth", Quote: "usdt", Precision: 1e2,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.01, MaxPrice: 100000, TickSize: 0.01},
+			LotSize:     exchange.LotSize{MinQty: 0.0001, MaxQty: 10000, StepSize: 0.0001},
+			MinNotional: 5, QuantityPrecision: 1e4},
+		{Name: "XRPUSDT", Base: "xrp", Quote: "usdt", Precision: 1e5,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.00001, MaxPrice: 1000, TickSize: 0.00001},
+			LotSize:     exchange.LotSize{MinQty: 0.1, MaxQty: 10000000, StepSize: 0.1},
+			MinNotional: 5, QuantityPrecision: 1e1},
+		{Name: "SHIBUSDT", Base: "shib", Quote: "usdt", Precision: 1e8,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.00000001, MaxPrice: 1, TickSize: 0.00000001},
+			LotSize:     exchange.LotSize{MinQty: 1, MaxQty: 10000000000, StepSize: 1},
+			MinNotional: 5, QuantityPrecision: 1},
+		{Name: "KUSDCUSDT", Base: "kusdc", Quote: "usdt", Precision: 1e8, Status: exchange.StatusHalted,
+			PriceFilter: exchange.PriceFilter{MinPrice: 0.00000001, MaxPrice: 10, TickSize: 0.00000001},
+			LotSize:     exchange.LotSize{MinQty: 0.01, MaxQty: 100000000, StepSize: 0.01},
+			MinNotional: 5, QuantityPrecision: 1e2},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+}
+
+func TestPrices(t *testing.T) {
+	res := exchangetest.Prices{}
+	if err := testExchange(t).Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected := exchangetest.Prices{
+		"BTCUSDT":   {68010.51, 68012.07},
+		"ETHUSDT":   {2601.44, 2601.58},
+		"XRPUSDT":   {0.54211, 0.54219},
+		"SHIBUSDT":  {0.00001811, 0.00001813},
+		"KUSDCUSDT": {0.0000000156, 0.00000013},
+		"SYLOUSDT":  {0.00076, 0.0009},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestHostOverrides(t *testing.T) {
+	markets := exchangetest.Server(t, map[string][]string{
+		"/api/v1/exchangeInfo": {"testdata/exchangeInfo.json"},
+	})
+	prices := exchangetest.Server(t, map[string][]string{
+		"/api/v1/ticker/24hr": {"testdata/ticker24hr.json"},
+	})
+	e := http.Must(bitrue.New(exchange.WithHost(bitrue.MarketsBaseURL, markets.URL), exchange.WithHost(bitrue.PricesBaseURL, prices.URL)))
+
+	res, err := e.Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 5 {
+		t.Errorf("expected 5 markets, got %d", len(res))
+	}
+	recorder := exchangetest.Prices{}
+	if err = e.Prices(recorder); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder) != 6 {
+		t.Errorf("expected 6 prices, got %d", len(recorder))
+	}
+}
+
+func TestMarketsStatusChanges(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v1/exchangeInfo": {"testdata/exchangeInfo.json", "testdata/exchangeInfo2.json"},
+	})
+	e := http.Must(bitrue.New(exchange.WithBaseURL(srv.URL)))
+	prev, err := e.Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := e.Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.StatusChange{
+		{Name: "ETHUSDT", From: exchange.StatusTrading, To: exchange.StatusHalted},
+		{Name: "KUSDCUSDT", From: exchange.StatusHalted, To: exchange.StatusTrading},
+	}
+	if res := exchange.StatusChanges(prev, next); !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{"/api/v1/time": {"testdata/time.json"}})
+	// The time is served by the markets host
+	e := http.Must(bitrue.New(exchange.WithHost(bitrue.MarketsBaseURL, srv.URL)))
+	res, err := e.ServerTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal(time.UnixMilli(1729339200123)) {
+		t.Errorf("unexpected server time %v", res)
+	}
+}
@@ -0,0 +1,198 @@
+package bybit
+
+import (
+	"context"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"strconv"
+	"time"
+)
+
+// Category is the Bybit v5 product category. Every category is a separate exchange,
+// so spot and perpetual books of the same symbol never share a frame.
+type Category string
+
+const (
+	Spot   Category = "spot"
+	Linear Category = "linear"
+)
+
+const SpotID types.ExchangeID = 4
+const LinearID types.ExchangeID = 5
+
+const SpotName = "bybit"
+const LinearName = "bybit-linear"
+
+const baseURL = "https://api.bybit.com"
+const marketsPath = "/v5/market/instruments-info?limit=1000&category="
+const pricesPath = "/v5/market/tickers?category="
+const timePath = "/v5/market/time"
+
+// Limits of the API, requests are counted per IP. Spot and linear categories share them.
+var Limits = http.Limits{
+	Limits: []http.Limit{{Weight: 600, Window: 5 * time.Second}},
+}
+
+var governor = http.NewGovernor(Limits)
+
+// parseErrors reads error bodies like {"retCode":10001,"retMsg":"params error"}
+var parseErrors = http.WithErrorParser(http.JSONErrorParser("retCode", "retMsg"))
+
+// envelopeErrors fails 200 responses with a non-zero retCode, the way most Bybit errors are returned.
+var envelopeErrors = http.WithEnvelopeCheck(http.JSONEnvelopeParser("retCode", "retMsg", "0"))
+
+// pricesLevel enters the result list: {"retCode":0,"retMsg":"OK","result":{"category":"spot","list":[...]}}
+const pricesLevel = 3
+
+type bookPrices struct {
+	Symbol string  `json:"symbol"`
+	Bid    float64 `json:"bid1Price"`
+	Ask    float64 `json:"ask1Price"`
+}
+
+type serverTime struct {
+	Result struct {
+		TimeNano string `json:"timeNano"`
+	} `json:"result"`
+}
+
+type instrumentsInfo struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		Category string `json:"category"`
+		List     []struct {
+			Symbol       string `json:"symbol"`
+			ContractType string `json:"contractType"`
+			Status       string `json:"status"`
+			BaseCoin     string `json:"baseCoin"`
+			QuoteCoin    string `json:"quoteCoin"`
+			SettleCoin   string `json:"settleCoin"`
+			PriceFilter  struct {
+				TickSize string `json:"tickSize"`
+			} `json:"priceFilter"`
+		} `json:"list"`
+	} `json:"result"`
+}
+
+func init() {
+	exchange.Register(SpotName, SpotID, factory(Spot))
+	exchange.Register(LinearName, LinearID, factory(Linear))
+}
+
+func factory(category Category) exchange.Factory {
+	return func(opts ...exchange.Option) (exchange.Exchange, error) {
+		e, err := New(category, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+}
+
+type Exchange struct {
+	category       Category
+	marketsFetcher http.CallFetch[instrumentsInfo]
+	priceFetcher   http.CallIterate[bookPrices]
+	timeFetcher    http.CallFetch[serverTime]
+}
+
+// New creates the exchange of the category, failing on a malformed base url of the options.
+func New(category Category, opts ...exchange.Option) (*Exchange, error) {
+	cfg := exchange.NewConfig(opts...)
+	cfg.Client = cfg.Govern(governor)
+	res := &Exchange{category: category}
+	marketsTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, marketsPath+string(category)), http.WithHeaders(http.WithCompression()), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.marketsFetcher = http.TemplateFetcher[instrumentsInfo](cfg.Client, marketsTemplate)
+	pricesTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, pricesPath+string(category)), http.WithHeaders(http.WithCompression()), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.priceFetcher = http.TemplateIterator[bookPrices](cfg.Client, pricesTemplate, pricesLevel)
+	timeTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, timePath), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.timeFetcher = http.TemplateFetcher[serverTime](cfg.Client, timeTemplate)
+	return res, nil
+}
+
+func (e *Exchange) ID() types.ExchangeID {
+	if e.category == Linear {
+		return LinearID
+	}
+	return SpotID
+}
+
+func (e *Exchange) Name() string {
+	if e.category == Linear {
+		return LinearName
+	}
+	return SpotName
+}
+
+func (e *Exchange) Category() Category {
+	return e.category
+}
+
+func (e *Exchange) Prices(buf scrap.IPriceWriter) (err error) {
+	return e.priceFetcher(context.Background(), func(v bookPrices) error {
+		if v.Bid > 0. && v.Ask > 0. {
+			if err = buf.Write(v.Symbol, v.Bid, v.Ask); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ServerTime returns the time of the exchange servers, shared by the categories.
+func (e *Exchange) ServerTime(ctx context.Context) (time.Time, error) {
+	var res serverTime
+	if err := e.timeFetcher(ctx, &res); err != nil {
+		return time.Time{}, err
+	}
+	ns, err := strconv.ParseInt(res.Result.TimeNano, 10, 64)
+	if err != nil || ns <= 0 {
+		return time.Time{}, fmt.Errorf("bybit: missing server time %q", res.Result.TimeNano)
+	}
+	return time.Unix(0, ns), nil
+}
+
+// Markets returns trading spot markets, or USDT margined perpetuals for the linear category.
+func (e *Exchange) Markets(ctx context.Context) (res []exchange.Market, err error) {
+	var info instrumentsInfo
+	if err = e.marketsFetcher(ctx, &info); err != nil {
+		return nil, err
+	}
+	if info.RetCode != 0 {
+		return nil, fmt.Errorf("bybit: instruments error %d: %s", info.RetCode, info.RetMsg)
+	}
+
+	for _, inst := range info.Result.List {
+		if inst.Status != "Trading" {
+			continue
+		}
+		if e.category == Linear && (inst.ContractType != "LinearPerpetual" || inst.SettleCoin != "USDT") {
+			continue
+		}
+		m := exchange.Market{
+			Name:      inst.Symbol,
+			Base:      inst.BaseCoin,
+			Quote:     inst.QuoteCoin,
+			Precision: exchange.TickPrecision(inst.PriceFilter.TickSize),
+		}
+		if e.category == Linear {
+			// Linear contracts are quoted in a single unit of the base coin
+			m.Type, m.ContractSize = exchange.Perpetual, 1
+		}
+		res = append(res, m)
+	}
+	return
+}
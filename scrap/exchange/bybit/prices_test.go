@@ -0,0 +1,119 @@
+package bybit_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/bybit"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testExchange(t *testing.T, category bybit.Category) *bybit.Exchange {
+	return exchangetest.Exchange(t, map[string][]string{
+		"/v5/market/instruments-info?limit=1000&category=spot":   {"testdata/instruments_spot.json"},
+		"/v5/market/instruments-info?limit=1000&category=linear": {"testdata/instruments_linear.json"},
+		"/v5/market/tickers?category=spot":                       {"testdata/tickers_spot.json"},
+		"/v5/market/tickers?category=linear":                     {"testdata/tickers_linear.json"},
+	}, newCategory(category))
+}
+
+// newCategory is the constructor of exchanges of the category.
+func newCategory(category bybit.Category) func(opts ...exchange.Option) (*bybit.Exchange, error) {
+	return func(opts ...exchange.Option) (*bybit.Exchange, error) {
+		return bybit.New(category, opts...)
+	}
+}
+
+func TestCategoriesAreDistinctExchanges(t *testing.T) {
+	spot, linear := http.Must(bybit.New(bybit.Spot)), http.Must(bybit.New(bybit.Linear))
+	if spot.ID() == linear.ID() || spot.Name() == linear.Name() {
+		t.Fatalf("spot and linear share exchange %d %s", spot.ID(), spot.Name())
+	}
+}
+
+func TestMarkets(t *testing.T) {
+	markets, err := testExchange(t, bybit.Spot).Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.Market{
+		{Name: "BTCUSDT", Base: "BTC", Quote: "USDT", Precision: 1e2},
+		{Name: "ETHUSDT", Base: "ETH", Quote: "USDT", Precision: 1e2},
+		{Name: "XRPUSDT", Base: "XRP", Quote: "USDT", Precision: 1e4},
+		{Name: "PEPEUSDT", Base: "PEPE", Quote: "USDT", Precision: 1e8},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+
+	markets, err = testExchange(t, bybit.Linear).Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []exchange.Market{
+		{Name: "BTCUSDT", Base: "BTC", Quote: "USDT", Precision: 1e1, Type: exchange.Perpetual, ContractSize: 1},
+		{Name: "ETHUSDT", Base: "ETH", Quote: "USDT", Precision: 1e2, Type: exchange.Perpetual, ContractSize: 1},
+		{Name: "1000PEPEUSDT", Base: "1000PEPE", Quote: "USDT", Precision: 1e7, Type: exchange.Perpetual, ContractSize: 1},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+}
+
+func TestPrices(t *testing.T) {
+	res := exchangetest.Prices{}
+	if err := testExchange(t, bybit.Spot).Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected := exchangetest.Prices{
+		"BTCUSDT":  {68012.01, 68012.02},
+		"ETHUSDT":  {2601.55, 2601.56},
+		"XRPUSDT":  {0.5421, 0.5422},
+		"PEPEUSDT": {0.00001001, 0.00001002},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+
+	res = exchangetest.Prices{}
+	if err := testExchange(t, bybit.Linear).Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected = exchangetest.Prices{
+		"BTCUSDT":      {68030.1, 68030.2},
+		"ETHUSDT":      {2602.11, 2602.12},
+		"1000PEPEUSDT": {0.0100113, 0.0100114},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	e := exchangetest.Exchange(t, map[string][]string{
+		"/v5/market/instruments-info": {"testdata/error.json"},
+		"/v5/market/tickers":          {"testdata/error.json"},
+	}, newCategory(bybit.Spot))
+	var se *http.StatusError
+	if _, err := e.Markets(context.Background()); !errors.As(err, &se) || se.Code != "10001" {
+		t.Errorf("expected the envelope error, got %v", err)
+	}
+	if err := e.Prices(exchangetest.Prices{}); !errors.As(err, &se) || se.StatusCode != 200 || se.Message != "params error: Category is invalid" {
+		t.Errorf("expected the envelope error, got %v", err)
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	e := exchangetest.Exchange(t, map[string][]string{"/v5/market/time": {"testdata/time.json"}}, newCategory(bybit.Linear))
+	res, err := e.ServerTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal(time.Unix(0, 1729339200123456789)) {
+		t.Errorf("unexpected server time %v", res)
+	}
+}
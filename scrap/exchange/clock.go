@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ServerTimer is implemented by exchanges publishing the time of their servers.
+type ServerTimer interface {
+	ServerTime(ctx context.Context) (time.Time, error)
+}
+
+// ClockSample is a single measurement of the exchange clock.
+type ClockSample struct {
+	// At is the local time the response was received.
+	At time.Time
+	// Offset is the exchange time minus the local time at the midpoint of the request.
+	Offset time.Duration
+	// RTT is the round trip of the request.
+	RTT time.Duration
+}
+
+// Clock tracks the offset of an exchange clock from the local one. It keeps the latest samples
+// and trusts the one of the shortest round trip, whose midpoint estimate has the smallest error.
+// Clock is safe for concurrent use.
+type Clock struct {
+	mu      sync.Mutex
+	samples []ClockSample
+	next    int
+	now     func() time.Time
+}
+
+// NewClock creates a clock keeping the given number of latest samples.
+func NewClock(size int) *Clock {
+	if size < 1 {
+		size = 1
+	}
+	return &Clock{samples: make([]ClockSample, 0, size), now: time.Now}
+}
+
+// Add records a server time received for a request sent and answered at the given local times.
+func (c *Clock) Add(sent, server, received time.Time) ClockSample {
+	rtt := received.Sub(sent)
+	if rtt < 0 {
+		rtt = 0
+	}
+	s := ClockSample{At: received, Offset: server.Sub(sent.Add(rtt / 2)), RTT: rtt}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.samples) < cap(c.samples) {
+		c.samples = append(c.samples, s)
+		return s
+	}
+	c.samples[c.next] = s
+	c.next = (c.next + 1) % len(c.samples)
+	return s
+}
+
+// Sample requests the server time of the exchange and records it.
+func (c *Clock) Sample(ctx context.Context, t ServerTimer) (ClockSample, error) {
+	sent := c.now()
+	server, err := t.ServerTime(ctx)
+	if err != nil {
+		return ClockSample{}, err
+	}
+	return c.Add(sent, server, c.now()), nil
+}
+
+// Run samples the server time every interval until the context is done. Failed samples are skipped.
+func (c *Clock) Run(ctx context.Context, t ServerTimer, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, _ = c.Sample(ctx, t)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Best returns the sample of the shortest round trip, false if there are none.
+func (c *Clock) Best() (res ClockSample, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, s := range c.samples {
+		if i == 0 || s.RTT < res.RTT {
+			res = s
+		}
+	}
+	return res, len(c.samples) > 0
+}
+
+// Offset returns the estimated exchange time minus the local time, zero until sampled.
+func (c *Clock) Offset() time.Duration {
+	s, _ := c.Best()
+	return s.Offset
+}
+
+// RTT returns the round trip of the sample the offset is estimated from.
+func (c *Clock) RTT() time.Duration {
+	s, _ := c.Best()
+	return s.RTT
+}
+
+// Now returns the current exchange time.
+func (c *Clock) Now() time.Time {
+	return c.now().Add(c.Offset())
+}
@@ -0,0 +1,66 @@
+package exchange_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"testing"
+	"time"
+)
+
+func TestClockTrustsShortestRoundTrip(t *testing.T) {
+	c := exchange.NewClock(3)
+	if _, ok := c.Best(); ok || c.Offset() != 0 {
+		t.Fatal("expected no offset before the first sample")
+	}
+	local := time.UnixMilli(1_700_000_000_000)
+	// The exchange clock is 1.5s ahead, answers arrive at different points of the round trips
+	c.Add(local, local.Add(1500*time.Millisecond+300*time.Millisecond), local.Add(400*time.Millisecond))
+	c.Add(local.Add(time.Second), local.Add(2500*time.Millisecond+10*time.Millisecond), local.Add(time.Second+20*time.Millisecond))
+	c.Add(local.Add(2*time.Second), local.Add(3500*time.Millisecond+90*time.Millisecond), local.Add(2*time.Second+100*time.Millisecond))
+
+	if c.RTT() != 20*time.Millisecond {
+		t.Errorf("expected rtt 20ms, got %v", c.RTT())
+	}
+	if c.Offset() != 1500*time.Millisecond {
+		t.Errorf("expected offset 1.5s, got %v", c.Offset())
+	}
+
+	// The best sample is dropped once the ring is full of newer ones
+	for i := 0; i < 3; i++ {
+		sent := local.Add(time.Duration(3+i) * time.Second)
+		c.Add(sent, sent.Add(time.Second+25*time.Millisecond), sent.Add(50*time.Millisecond))
+	}
+	if c.Offset() != time.Second || c.RTT() != 50*time.Millisecond {
+		t.Errorf("expected offset 1s with rtt 50ms, got %v with %v", c.Offset(), c.RTT())
+	}
+}
+
+type serverTimer struct {
+	offset time.Duration
+	err    error
+}
+
+func (s serverTimer) ServerTime(ctx context.Context) (time.Time, error) {
+	return time.Now().Add(s.offset), s.err
+}
+
+func TestClockSample(t *testing.T) {
+	c := exchange.NewClock(4)
+	if _, err := c.Sample(context.Background(), serverTimer{err: errors.New("down")}); err == nil {
+		t.Fatal("expected the error of the server")
+	}
+	if _, ok := c.Best(); ok {
+		t.Fatal("failed sample must not be recorded")
+	}
+	s, err := c.Sample(context.Background(), serverTimer{offset: -3 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := s.Offset + 3*time.Second; d < -10*time.Millisecond || d > 10*time.Millisecond {
+		t.Errorf("expected offset -3s, got %v", s.Offset)
+	}
+	if d := time.Until(c.Now()) + 3*time.Second; d < -10*time.Millisecond || d > 10*time.Millisecond {
+		t.Errorf("expected exchange time 3s behind, got %v", c.Now())
+	}
+}
@@ -0,0 +1,246 @@
+package coinbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"sync"
+	"time"
+)
+
+const ID types.ExchangeID = 7
+const Name = "coinbase"
+
+const baseURL = "https://api.exchange.coinbase.com"
+const marketsPath = "/products"
+const tickerPath = "/products/%s/ticker"
+const timePath = "/time"
+
+// Limits of the public API. Polling tickers of all products is paced by them.
+var Limits = http.Limits{
+	Limits: []http.Limit{{Weight: 10, Window: time.Second}},
+}
+
+var governor = http.NewGovernor(Limits)
+
+// parseErrors reads error bodies like {"message":"NotFound"}, Coinbase errors have no code.
+var parseErrors = http.WithErrorParser(http.JSONErrorParser("", "message"))
+
+// pollConcurrency bounds requests in flight while polling tickers product by product.
+const pollConcurrency = 8
+
+// ErrNotListed is returned by Prices called before the products are listed by Markets.
+var ErrNotListed = errors.New("coinbase: products are not listed, call Markets first")
+
+type product struct {
+	ID              string `json:"id"`
+	BaseCurrency    string `json:"base_currency"`
+	QuoteCurrency   string `json:"quote_currency"`
+	QuoteIncrement  string `json:"quote_increment"`
+	Status          string `json:"status"`
+	TradingDisabled bool   `json:"trading_disabled"`
+}
+
+type serverTime struct {
+	ISO time.Time `json:"iso"`
+}
+
+type ticker struct {
+	Bid types.StringToFloat `json:"bid"`
+	Ask types.StringToFloat `json:"ask"`
+}
+
+func init() {
+	exchange.Register(Name, ID, func(opts ...exchange.Option) (exchange.Exchange, error) {
+		e, err := New(opts...)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	})
+}
+
+// Exchange polls tickers of the products listed by the last Markets call.
+// Coinbase has no bulk book ticker endpoint, so a poll of n products takes n/10 seconds at the public
+// limit, about a minute for all listed products. Restrict the poll to the markets of the table with
+// PollMarkets, or use Stream to receive prices of all products at once.
+type Exchange struct {
+	cfg            exchange.Config
+	marketsFetcher http.CallFetch[[]product]
+	timeFetcher    http.CallFetch[serverTime]
+
+	mu      sync.Mutex
+	tickers []productTicker
+	// polled are the products polled by Prices, all of them when nil
+	polled map[string]bool
+}
+
+type productTicker struct {
+	name  string
+	fetch http.CallFetch[ticker]
+}
+
+type tickerResult struct {
+	name string
+	ticker
+	err error
+}
+
+// New creates the exchange, failing on a malformed base url of the options.
+func New(opts ...exchange.Option) (*Exchange, error) {
+	cfg := exchange.NewConfig(opts...)
+	cfg.Client = cfg.Govern(governor)
+	marketsTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, marketsPath), http.WithHeaders(http.WithCompression()), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	timeTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, timePath), parseErrors)
+	if err != nil {
+		return nil, err
+	}
+	return &Exchange{
+		cfg:            cfg,
+		marketsFetcher: http.TemplateFetcher[[]product](cfg.Client, marketsTemplate),
+		timeFetcher:    http.TemplateFetcher[serverTime](cfg.Client, timeTemplate),
+	}, nil
+}
+
+func (e *Exchange) ID() types.ExchangeID {
+	return ID
+}
+
+func (e *Exchange) Name() string {
+	return Name
+}
+
+// ServerTime returns the time of the exchange servers.
+func (e *Exchange) ServerTime(ctx context.Context) (time.Time, error) {
+	var res serverTime
+	if err := e.timeFetcher(ctx, &res); err != nil {
+		return time.Time{}, err
+	}
+	if res.ISO.IsZero() {
+		return time.Time{}, errors.New("coinbase: missing server time")
+	}
+	return res.ISO, nil
+}
+
+// PollMarkets restricts Prices to the products of the names, all online products are polled when nil.
+func (e *Exchange) PollMarkets(names []string) {
+	var polled map[string]bool
+	if names != nil {
+		polled = make(map[string]bool, len(names))
+		for _, name := range names {
+			polled[name] = true
+		}
+	}
+	e.mu.Lock()
+	e.polled = polled
+	e.mu.Unlock()
+}
+
+// Prices polls the ticker of every polled product listed by Markets with at most pollConcurrency requests in flight.
+// Prices are written from the calling goroutine. A failed product does not stop others,
+// the first error is returned once all products are polled.
+func (e *Exchange) Prices(buf scrap.IPriceWriter) (err error) {
+	e.mu.Lock()
+	listed := e.tickers
+	e.mu.Unlock()
+	if listed == nil {
+		return ErrNotListed
+	}
+	tickers := e.polledTickers()
+
+	jobs := make(chan productTicker)
+	results := make(chan tickerResult)
+	var wg sync.WaitGroup
+	for i := 0; i < min(pollConcurrency, len(tickers)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				res := tickerResult{name: t.name}
+				res.err = t.fetch(context.Background(), &res.ticker)
+				results <- res
+			}
+		}()
+	}
+	go func() {
+		for _, t := range tickers {
+			jobs <- t
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	var fetchErr error
+	for res := range results {
+		if res.err != nil {
+			if fetchErr == nil {
+				fetchErr = res.err
+			}
+			continue
+		}
+		if err == nil && res.Bid > 0. && res.Ask > 0. {
+			err = buf.Write(res.name, res.Bid.Float(), res.Ask.Float())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return fetchErr
+}
+
+func (e *Exchange) polledTickers() []productTicker {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.polled == nil {
+		return e.tickers
+	}
+	res := make([]productTicker, 0, len(e.polled))
+	for _, t := range e.tickers {
+		if e.polled[t.name] {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// Markets returns online products and prepares the tickers polled by Prices.
+func (e *Exchange) Markets(ctx context.Context) (res []exchange.Market, err error) {
+	var products []product
+	if err = e.marketsFetcher(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	tickers := make([]productTicker, 0, len(products))
+	for _, p := range products {
+		if p.Status != "online" || p.TradingDisabled {
+			continue
+		}
+		tickerTemplate, tErr := http.NewTemplate("GET", e.cfg.URL(baseURL, fmt.Sprintf(tickerPath, p.ID)), http.WithHeaders(http.WithCompression()), parseErrors)
+		if tErr != nil {
+			return nil, tErr
+		}
+		res = append(res, exchange.Market{
+			Name:      p.ID,
+			Base:      p.BaseCurrency,
+			Quote:     p.QuoteCurrency,
+			Precision: exchange.TickPrecision(p.QuoteIncrement),
+		})
+		tickers = append(tickers, productTicker{
+			name:  p.ID,
+			fetch: http.TemplateFetcher[ticker](e.cfg.Client, tickerTemplate),
+		})
+	}
+
+	e.mu.Lock()
+	e.tickers = tickers
+	e.mu.Unlock()
+	return
+}
@@ -0,0 +1,132 @@
+package coinbase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/coinbase"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"github.com/dk-open/crypto-zip/types"
+	nethttp "net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testExchange(t *testing.T) *coinbase.Exchange {
+	return exchangetest.Exchange(t, map[string][]string{
+		"/products":                 {"testdata/products.json"},
+		"/products/BTC-USD/ticker":  {"testdata/ticker_BTC-USD.json"},
+		"/products/ETH-USD/ticker":  {"testdata/ticker_ETH-USD.json"},
+		"/products/SHIB-USD/ticker": {"testdata/ticker_SHIB-USD.json"},
+	}, coinbase.New)
+}
+
+// listed returns the exchange with its products listed, as Prices requires.
+func listed(t *testing.T, e *coinbase.Exchange) *coinbase.Exchange {
+	t.Helper()
+	if _, err := e.Markets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestMarkets(t *testing.T) {
+	markets, err := testExchange(t).Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.Market{
+		{Name: "BTC-USD", Base: "BTC", Quote: "USD", Precision: 1e2},
+		{Name: "ETH-USD", Base: "ETH", Quote: "USD", Precision: 1e2},
+		{Name: "SHIB-USD", Base: "SHIB", Quote: "USD", Precision: 1e8},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+}
+
+func TestPrices(t *testing.T) {
+	res := exchangetest.Prices{}
+	if err := listed(t, testExchange(t)).Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected := exchangetest.Prices{
+		"BTC-USD":  {68011.27, 68011.28},
+		"ETH-USD":  {2600.91, 2600.92},
+		"SHIB-USD": {0.00001789, 0.0000179},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestPricesNotListed(t *testing.T) {
+	if err := testExchange(t).Prices(exchangetest.Prices{}); !errors.Is(err, coinbase.ErrNotListed) {
+		t.Errorf("expected ErrNotListed, got %v", err)
+	}
+}
+
+func TestPricesFailedProduct(t *testing.T) {
+	e := exchangetest.Exchange(t, map[string][]string{
+		"/products":                {"testdata/products.json"},
+		"/products/BTC-USD/ticker": {"testdata/ticker_BTC-USD.json"},
+	}, coinbase.New)
+	res := exchangetest.Prices{}
+	if err := listed(t, e).Prices(res); err == nil {
+		t.Fatal("expected an error of products without ticker")
+	}
+	if res["BTC-USD"] != (types.Price{68011.27, 68011.28}) {
+		t.Errorf("prices of other products must be written, got %v", res)
+	}
+}
+
+func TestPollMarkets(t *testing.T) {
+	// ETH-USD has no ticker and fails unless excluded from the poll
+	e := listed(t, exchangetest.Exchange(t, map[string][]string{
+		"/products":                 {"testdata/products.json"},
+		"/products/BTC-USD/ticker":  {"testdata/ticker_BTC-USD.json"},
+		"/products/SHIB-USD/ticker": {"testdata/ticker_SHIB-USD.json"},
+	}, coinbase.New))
+	e.PollMarkets([]string{"BTC-USD", "SHIB-USD"})
+
+	res := exchangetest.Prices{}
+	if err := e.Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected := exchangetest.Prices{
+		"BTC-USD":  {68011.27, 68011.28},
+		"SHIB-USD": {0.00001789, 0.0000179},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+
+	var _ exchange.MarketPoller = e
+}
+
+func TestErrorMessage(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"NotFound"}`))
+	}))
+	t.Cleanup(srv.Close)
+	_, err := http.Must(coinbase.New(exchange.WithBaseURL(srv.URL))).Markets(context.Background())
+	var se *http.StatusError
+	if !errors.As(err, &se) || se.StatusCode != 404 || se.Message != "NotFound" {
+		t.Errorf("expected the error message, got %v", err)
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	e := exchangetest.Exchange(t, map[string][]string{"/time": {"testdata/time.json"}}, coinbase.New)
+	res, err := e.ServerTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal(time.UnixMilli(1729339200123)) {
+		t.Errorf("unexpected server time %v", res)
+	}
+}
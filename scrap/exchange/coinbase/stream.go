@@ -0,0 +1,111 @@
+package coinbase
+
+import (
+	"context"
+	"fmt"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"github.com/goccy/go-json"
+	"golang.org/x/net/websocket"
+	"strings"
+	"time"
+)
+
+const streamURL = "wss://ws-feed.exchange.coinbase.com"
+const streamPath = ""
+
+// readTimeout detects dead connections. Coinbase pings every minute and pongs are
+// replied by the websocket frame handler while reading.
+const readTimeout = 90 * time.Second
+
+type subscribeRequest struct {
+	Type       string   `json:"type"`
+	ProductIDs []string `json:"product_ids"`
+	Channels   []string `json:"channels"`
+}
+
+type streamMessage struct {
+	Type      string              `json:"type"`
+	Message   string              `json:"message"`
+	Reason    string              `json:"reason"`
+	ProductID string              `json:"product_id"`
+	BestBid   types.StringToFloat `json:"best_bid"`
+	BestAsk   types.StringToFloat `json:"best_ask"`
+}
+
+// Stream writes ticker channel updates of the markets until the context is done.
+// The connection is reconnected with backoff.
+func (e *Exchange) Stream(ctx context.Context, markets []exchange.Market, w scrap.IPriceWriter) error {
+	products := make([]string, 0, len(markets))
+	for _, m := range markets {
+		products = append(products, m.Name)
+	}
+
+	attempt := 0
+	for ctx.Err() == nil {
+		received, _ := e.stream(ctx, products, w)
+		if received {
+			attempt = 0
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(e.cfg.Backoff.Delay(attempt)):
+		}
+		attempt++
+	}
+	return ctx.Err()
+}
+
+// stream runs a single connection and reports whether any price has been received.
+func (e *Exchange) stream(ctx context.Context, products []string, w scrap.IPriceWriter) (received bool, err error) {
+	endpoint := e.cfg.StreamEndpoint(streamURL, streamPath)
+	wsCfg, err := websocket.NewConfig(endpoint, strings.Replace(endpoint, "ws", "http", 1))
+	if err != nil {
+		return false, err
+	}
+	conn, err := wsCfg.DialContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-connCtx.Done()
+		conn.Close()
+	}()
+
+	if err = websocket.JSON.Send(conn, subscribeRequest{Type: "subscribe", ProductIDs: products, Channels: []string{"ticker"}}); err != nil {
+		return false, err
+	}
+
+	var msg streamMessage
+	for {
+		if err = conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			return received, err
+		}
+		var data []byte
+		if err = websocket.Message.Receive(conn, &data); err != nil {
+			if ctx.Err() != nil {
+				return received, ctx.Err()
+			}
+			return received, err
+		}
+		msg = streamMessage{}
+		if err = json.Unmarshal(data, &msg); err != nil {
+			return received, err
+		}
+		switch msg.Type {
+		case "ticker":
+			if msg.BestBid > 0. && msg.BestAsk > 0. {
+				if err = w.Write(msg.ProductID, msg.BestBid.Float(), msg.BestAsk.Float()); err != nil {
+					return received, err
+				}
+				received = true
+			}
+		case "error":
+			return received, fmt.Errorf("coinbase: %s: %s", msg.Message, msg.Reason)
+		}
+	}
+}
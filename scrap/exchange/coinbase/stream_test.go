@@ -0,0 +1,113 @@
+package coinbase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/coinbase"
+	"github.com/dk-open/crypto-zip/types"
+	"golang.org/x/net/websocket"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type subscribeRequest struct {
+	Type       string   `json:"type"`
+	ProductIDs []string `json:"product_ids"`
+	Channels   []string `json:"channels"`
+}
+
+type streamRecorder struct {
+	mu     sync.Mutex
+	prices map[string]types.Price
+	notify chan struct{}
+}
+
+func (r *streamRecorder) Write(name string, bid, ask float64) error {
+	r.mu.Lock()
+	r.prices[name] = types.Price{bid, ask}
+	r.mu.Unlock()
+	r.notify <- struct{}{}
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	var mu sync.Mutex
+	var requests []subscribeRequest
+	srv := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var req subscribeRequest
+		if err := websocket.JSON.Receive(conn, &req); err != nil {
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		n := len(requests)
+		mu.Unlock()
+
+		messages := []string{
+			`{"type":"subscriptions","channels":[{"name":"ticker","product_ids":["BTC-USD","ETH-USD"]}]}`,
+			`{"type":"ticker","sequence":1,"product_id":"ETH-USD","price":"2600.91","best_bid":"2600.91","best_bid_size":"1.2","best_ask":"2600.92","best_ask_size":"0.3"}`,
+		}
+		if n == 1 {
+			// The first connection fails and is reconnected
+			messages = append(messages, `{"type":"error","message":"Failed to subscribe","reason":"test"}`)
+		} else {
+			messages = append(messages, `{"type":"ticker","sequence":2,"product_id":"BTC-USD","price":"68011.27","best_bid":"68011.27","best_ask":"68011.28"}`)
+		}
+		for _, msg := range messages {
+			if err := websocket.Message.Send(conn, msg); err != nil {
+				return
+			}
+		}
+		var data []byte
+		_ = websocket.Message.Receive(conn, &data)
+	}))
+	defer srv.Close()
+
+	ex := http.Must(coinbase.New(
+		exchange.WithStreamURL(strings.Replace(srv.URL, "http", "ws", 1)),
+		exchange.WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+	))
+	recorder := &streamRecorder{prices: map[string]types.Price{}, notify: make(chan struct{}, 10)}
+	markets := []exchange.Market{{Name: "BTC-USD"}, {Name: "ETH-USD"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ex.Stream(ctx, markets, recorder)
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-recorder.notify:
+		case <-timeout:
+			t.Fatalf("prices of the reconnected stream not received: %v", recorder.prices)
+		}
+		recorder.mu.Lock()
+		price := recorder.prices["BTC-USD"]
+		recorder.mu.Unlock()
+		if price == (types.Price{68011.27, 68011.28}) {
+			break
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := subscribeRequest{Type: "subscribe", ProductIDs: []string{"BTC-USD", "ETH-USD"}, Channels: []string{"ticker"}}
+	if len(requests) != 2 || !reflect.DeepEqual(requests[1], expected) {
+		t.Errorf("unexpected subscriptions %v", requests)
+	}
+	if recorder.prices["ETH-USD"] != (types.Price{2600.91, 2600.92}) {
+		t.Errorf("unexpected ETH-USD price %v", recorder.prices["ETH-USD"])
+	}
+}
@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/types"
+	nethttp "net/http"
+	"time"
+)
+
+// Config holds settings shared by all exchange adapters.
+type Config struct {
+	// BaseURL replaces the scheme and host of every exchange endpoint, e.g. to point an adapter to a test server.
+	BaseURL string
+	// Hosts replaces production base URLs by the given ones, e.g. "https://openapi.bitrue.com". They take
+	// precedence over BaseURL and StreamURL, so that a single host of an exchange can be overridden.
+	Hosts  map[string]string
+	Client *nethttp.Client
+	// StreamURL replaces the scheme and host of websocket endpoints.
+	StreamURL string
+	// Backoff is the delay between reconnects of streams.
+	Backoff Backoff
+	// Assets receives aliases of exchange specific asset codes to canonical names.
+	Assets types.AssetMap
+	// Clock receives server times the exchange reports along with its responses.
+	Clock *Clock
+	// Retry retries failed requests of the adapter, every attempt takes its weight from the governor.
+	Retry *http.RetryPolicy
+}
+
+type Option func(c *Config)
+
+// WithBaseURL overrides the production endpoints host.
+func WithBaseURL(url string) Option {
+	return func(c *Config) {
+		c.BaseURL = url
+	}
+}
+
+// WithHost overrides endpoints of a single production base URL, e.g. "https://openapi.bitrue.com".
+func WithHost(productionBase, url string) Option {
+	return func(c *Config) {
+		if c.Hosts == nil {
+			c.Hosts = map[string]string{}
+		}
+		c.Hosts[productionBase] = url
+	}
+}
+
+// WithClient sets the client requests are sent with.
+func WithClient(client *nethttp.Client) Option {
+	return func(c *Config) {
+		c.Client = client
+	}
+}
+
+// WithStreamURL overrides the production websocket endpoints host.
+func WithStreamURL(url string) Option {
+	return func(c *Config) {
+		c.StreamURL = url
+	}
+}
+
+// WithBackoff sets the delay range between reconnects of streams.
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Config) {
+		c.Backoff = Backoff{Min: min, Max: max}
+	}
+}
+
+// WithAssets registers asset code aliases of the exchange into the map.
+func WithAssets(assets types.AssetMap) Option {
+	return func(c *Config) {
+		c.Assets = assets
+	}
+}
+
+// WithClock tracks the exchange clock from server times of responses.
+func WithClock(clock *Clock) Option {
+	return func(c *Config) {
+		c.Clock = clock
+	}
+}
+
+// WithRetry retries failed requests with the policy. Adapters send attempts through their governor, so
+// that retries count toward the exchange limits. Do not pass a client wrapped by the policy to WithClient.
+func WithRetry(policy *http.RetryPolicy) Option {
+	return func(c *Config) {
+		c.Retry = policy
+	}
+}
+
+// NewConfig applies options on top of the defaults.
+func NewConfig(opts ...Option) Config {
+	res := Config{Client: http.DefaultClient(), Backoff: Backoff{Min: time.Second, Max: time.Minute}}
+	for _, opt := range opts {
+		opt(&res)
+	}
+	return res
+}
+
+// Govern returns the client sending requests through the governor, retried by the retry policy if set.
+func (c Config) Govern(g *http.Governor) *nethttp.Client {
+	res := g.Client(c.Client)
+	if c.Retry != nil {
+		res = c.Retry.Client(res)
+	}
+	return res
+}
+
+// URL returns the endpoint on the host configured for the default base URL, on the configured base URL
+// or on the default one.
+func (c Config) URL(defaultBase, path string) string {
+	if host, ok := c.Hosts[defaultBase]; ok {
+		return host + path
+	}
+	if c.BaseURL != "" {
+		return c.BaseURL + path
+	}
+	return defaultBase + path
+}
+
+// StreamEndpoint returns the websocket endpoint on the host configured for the default base URL, on the
+// configured stream URL or on the default one.
+func (c Config) StreamEndpoint(defaultBase, path string) string {
+	if host, ok := c.Hosts[defaultBase]; ok {
+		return host + path
+	}
+	if c.StreamURL != "" {
+		return c.StreamURL + path
+	}
+	return defaultBase + path
+}
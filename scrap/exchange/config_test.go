@@ -0,0 +1,61 @@
+package exchange_test
+
+import (
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfigURL(t *testing.T) {
+	const spot, futures = "https://api.example.com", "https://fapi.example.com"
+	tests := []struct {
+		name     string
+		opts     []exchange.Option
+		expected [2]string
+	}{
+		{"defaults", nil, [2]string{spot + "/a", futures + "/b"}},
+		{"base", []exchange.Option{exchange.WithBaseURL("http://test")}, [2]string{"http://test/a", "http://test/b"}},
+		{"host", []exchange.Option{exchange.WithHost(futures, "http://f")}, [2]string{spot + "/a", "http://f/b"}},
+		{"host over base", []exchange.Option{exchange.WithBaseURL("http://test"), exchange.WithHost(futures, "http://f")},
+			[2]string{"http://test/a", "http://f/b"}},
+	}
+	for _, tt := range tests {
+		cfg := exchange.NewConfig(tt.opts...)
+		if res := [2]string{cfg.URL(spot, "/a"), cfg.URL(futures, "/b")}; res != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, res)
+		}
+	}
+}
+
+func TestConfigGovernRetries(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		requests.Add(1)
+		w.WriteHeader(nethttp.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	// Every attempt takes its weight, so the third one is rejected by the governor
+	g := http.NewGovernor(http.Limits{Limits: []http.Limit{{Weight: 2, Window: time.Hour}}, DefaultCost: 1, Reject: true})
+	policy := http.NewRetryPolicy(http.WithRetryBackoff(time.Millisecond, time.Millisecond))
+	cfg := exchange.NewConfig(exchange.WithClient(srv.Client()), exchange.WithRetry(policy))
+
+	resp, err := cfg.Govern(g).Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, http.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected 2 requests within the limit, got %d", n)
+	}
+	if stats := policy.Stats(); stats.Attempts != 3 || stats.Retries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
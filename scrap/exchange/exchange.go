@@ -1,8 +1,73 @@
 package exchange
 
+import (
+	"context"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/types"
+	"time"
+)
+
+// MarketType is the kind of instrument traded on a market.
+type MarketType uint8
+
+const (
+	Spot MarketType = iota
+	// Perpetual is a futures contract without expiry, kept close to the index price by funding.
+	Perpetual
+	// Future is a futures contract settled at its expiry.
+	Future
+)
+
+func (t MarketType) String() string {
+	switch t {
+	case Spot:
+		return "spot"
+	case Perpetual:
+		return "perp"
+	case Future:
+		return "future"
+	}
+	return "unknown"
+}
+
 type Market struct {
 	Name      string
 	Base      string
 	Quote     string
 	Precision float64
+	Type      MarketType
+	// ContractSize is the amount of the base asset per contract, it is zero for spot markets.
+	ContractSize float64
+	// Expiry is the settlement time of futures, it is zero for spot and perpetual markets.
+	Expiry time.Time
+	Status MarketStatus
+
+	// Trading rules, zero when the exchange does not publish them
+	PriceFilter PriceFilter
+	LotSize     LotSize
+	MinNotional float64
+	// QuantityPrecision is the multiplier turning quantities into integer lot steps.
+	QuantityPrecision float64
+}
+
+// Exchange is a venue markets and prices are scrapped from.
+type Exchange interface {
+	ID() types.ExchangeID
+	Name() string
+	// Markets lists markets of the exchange. Adapters reporting the status list markets of any status,
+	// so that transitions can be followed with StatusChanges.
+	Markets(ctx context.Context) ([]Market, error)
+	Prices(w scrap.IPriceWriter) error
+}
+
+// Streamer is implemented by exchanges pushing prices continuously instead of being polled.
+type Streamer interface {
+	Stream(ctx context.Context, markets []Market, w scrap.IPriceWriter) error
+}
+
+// MarketPoller is implemented by exchanges polling prices market by market. Polling only the markets
+// in use, e.g. those of the market table, keeps a scrape within the rate limits of the exchange.
+type MarketPoller interface {
+	// PollMarkets restricts Prices to the markets of the names, all listed markets are polled when nil.
+	PollMarkets(names []string)
 }
@@ -0,0 +1,27 @@
+package exchangetest
+
+import (
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"testing"
+)
+
+// Prices records the last prices written by markets.
+type Prices map[string]types.Price
+
+func (p Prices) Write(name string, bid, ask float64) error {
+	p[name] = types.Price{bid, ask}
+	return nil
+}
+
+// Exchange creates the exchange with the options and the base url of a Server of the routes,
+// failing the test on an error of the constructor.
+func Exchange[E any](t testing.TB, routes map[string][]string, create func(opts ...exchange.Option) (E, error), opts ...exchange.Option) E {
+	t.Helper()
+	srv := Server(t, routes)
+	res, err := create(append(opts, exchange.WithBaseURL(srv.URL))...)
+	if err != nil {
+		t.Fatalf("failed to create the exchange: %v", err)
+	}
+	return res
+}
@@ -0,0 +1,50 @@
+// Package exchangetest serves recorded exchange responses for offline adapter tests.
+package exchangetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+// Server serves fixture files by request path with query, or by path only when no route has the query.
+// Every request to a path returns the next
+// of its fixtures and the last one is repeated once all have been served.
+func Server(t testing.TB, routes map[string][]string) *httptest.Server {
+	t.Helper()
+	fixtures := make(map[string][][]byte, len(routes))
+	for path, files := range routes {
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+			fixtures[path] = append(fixtures[path], data)
+		}
+	}
+
+	var mu sync.Mutex
+	served := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.RequestURI()
+		if _, ok := fixtures[route]; !ok {
+			route = r.URL.Path
+		}
+		mu.Lock()
+		data, ok := fixtures[route]
+		n := served[route]
+		served[route]++
+		mu.Unlock()
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data[min(n, len(data)-1)])
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
@@ -0,0 +1,176 @@
+package exchange
+
+import (
+	"strconv"
+)
+
+// MarketStatus is the trading state of a market. The zero value is trading,
+// as adapters list trading markets only unless they report the status.
+type MarketStatus uint8
+
+const (
+	StatusTrading MarketStatus = iota
+	// StatusPreTrading is a market listed before trading starts.
+	StatusPreTrading
+	// StatusHalted is a market with trading suspended, like a Binance break.
+	StatusHalted
+	// StatusDelisted is a market settled or no longer listed by the exchange.
+	StatusDelisted
+)
+
+func (s MarketStatus) String() string {
+	switch s {
+	case StatusTrading:
+		return "trading"
+	case StatusPreTrading:
+		return "pre-trading"
+	case StatusHalted:
+		return "halted"
+	case StatusDelisted:
+		return "delisted"
+	}
+	return "unknown"
+}
+
+// ParseStatus maps statuses of Binance like exchangeInfo endpoints.
+func ParseStatus(status string) MarketStatus {
+	switch status {
+	case "TRADING":
+		return StatusTrading
+	case "PRE_TRADING", "PENDING_TRADING":
+		return StatusPreTrading
+	case "SETTLING", "DELIVERING", "DELIVERED", "CLOSE":
+		return StatusDelisted
+	}
+	// BREAK, HALT, AUCTION_MATCH, END_OF_DAY, POST_TRADING
+	return StatusHalted
+}
+
+// PriceFilter limits order prices.
+type PriceFilter struct {
+	MinPrice float64
+	MaxPrice float64
+	TickSize float64
+}
+
+// LotSize limits order quantities.
+type LotSize struct {
+	MinQty   float64
+	MaxQty   float64
+	StepSize float64
+}
+
+// Filter is a raw filter of Binance like exchangeInfo endpoints. Decimals are kept as strings
+// to derive precisions from the published digits.
+type Filter struct {
+	FilterType string `json:"filterType"`
+	MinPrice   string `json:"minPrice"`
+	MaxPrice   string `json:"maxPrice"`
+	TickSize   string `json:"tickSize"`
+	MinQty     string `json:"minQty"`
+	MaxQty     string `json:"maxQty"`
+	StepSize   string `json:"stepSize"`
+	// MinNotional is set by NOTIONAL and spot MIN_NOTIONAL filters
+	MinNotional string `json:"minNotional"`
+	// Notional is set by the futures MIN_NOTIONAL filter
+	Notional string `json:"notional"`
+	// MinVal is the minimal order value of the Bitrue LOT_SIZE filter
+	MinVal string `json:"minVal"`
+}
+
+// Filters are the trading rules of a market parsed from raw filters.
+type Filters struct {
+	Price       PriceFilter
+	Lot         LotSize
+	MinNotional float64
+	// PricePrecision and QuantityPrecision are multipliers turning prices and quantities into integer steps.
+	PricePrecision    float64
+	QuantityPrecision float64
+}
+
+// ParseFilters reads PRICE_FILTER, LOT_SIZE, MIN_NOTIONAL and NOTIONAL filters, others are ignored.
+func ParseFilters(filters []Filter) (res Filters) {
+	for _, f := range filters {
+		switch f.FilterType {
+		case "PRICE_FILTER":
+			res.Price = PriceFilter{MinPrice: parseFloat(f.MinPrice), MaxPrice: parseFloat(f.MaxPrice), TickSize: parseFloat(f.TickSize)}
+			if f.TickSize != "" {
+				res.PricePrecision = TickPrecision(f.TickSize)
+			}
+		case "LOT_SIZE":
+			res.Lot = LotSize{MinQty: parseFloat(f.MinQty), MaxQty: parseFloat(f.MaxQty), StepSize: parseFloat(f.StepSize)}
+			if f.StepSize != "" {
+				res.QuantityPrecision = TickPrecision(f.StepSize)
+			}
+			if f.MinVal != "" {
+				res.MinNotional = parseFloat(f.MinVal)
+			}
+		case "MIN_NOTIONAL", "NOTIONAL":
+			if f.MinNotional != "" {
+				res.MinNotional = parseFloat(f.MinNotional)
+			} else if f.Notional != "" {
+				res.MinNotional = parseFloat(f.Notional)
+			}
+		}
+	}
+	return
+}
+
+// Apply sets the trading rules of the market, the price precision is set when the tick size is known.
+func (f Filters) Apply(m *Market) {
+	m.PriceFilter = f.Price
+	m.LotSize = f.Lot
+	m.MinNotional = f.MinNotional
+	m.QuantityPrecision = f.QuantityPrecision
+	if f.PricePrecision > 0 {
+		m.Precision = f.PricePrecision
+	}
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+// Trading returns the markets open for trading, e.g. to build the market table of a scrapper.
+func Trading(markets []Market) []Market {
+	res := make([]Market, 0, len(markets))
+	for _, m := range markets {
+		if m.Status == StatusTrading {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// StatusChange is a transition of the market status between two listings.
+type StatusChange struct {
+	Name string
+	From MarketStatus
+	To   MarketStatus
+}
+
+// StatusChanges compares two listings of an exchange. Markets missing from the next listing are
+// reported as delisted and new markets as transitions from delisted.
+func StatusChanges(prev, next []Market) (res []StatusChange) {
+	prevStatus := make(map[string]MarketStatus, len(prev))
+	for _, m := range prev {
+		prevStatus[m.Name] = m.Status
+	}
+	for _, m := range next {
+		from, ok := prevStatus[m.Name]
+		if !ok {
+			from = StatusDelisted
+		}
+		if from != m.Status {
+			res = append(res, StatusChange{Name: m.Name, From: from, To: m.Status})
+		}
+		delete(prevStatus, m.Name)
+	}
+	for _, m := range prev {
+		if from, ok := prevStatus[m.Name]; ok && from != StatusDelisted {
+			res = append(res, StatusChange{Name: m.Name, From: from, To: StatusDelisted})
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package exchange_test
+
+import (
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilters(t *testing.T) {
+	res := exchange.ParseFilters([]exchange.Filter{
+		{FilterType: "PRICE_FILTER", MinPrice: "0.00010", MaxPrice: "200", TickSize: "0.00010"},
+		{FilterType: "LOT_SIZE", MinQty: "0.1", MaxQty: "1000000", StepSize: "0.1"},
+		{FilterType: "MARKET_LOT_SIZE", MinQty: "0", MaxQty: "5000", StepSize: "0"},
+		{FilterType: "MIN_NOTIONAL", Notional: "5"},
+	})
+	expected := exchange.Filters{
+		Price:             exchange.PriceFilter{MinPrice: 0.0001, MaxPrice: 200, TickSize: 0.0001},
+		Lot:               exchange.LotSize{MinQty: 0.1, MaxQty: 1000000, StepSize: 0.1},
+		MinNotional:       5,
+		PricePrecision:    1e4,
+		QuantityPrecision: 1e1,
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+
+	m := exchange.Market{Precision: 1}
+	exchange.ParseFilters(nil).Apply(&m)
+	if m.Precision != 1 {
+		t.Errorf("precision must be kept without a price filter, got %v", m.Precision)
+	}
+}
+
+func TestStatusChanges(t *testing.T) {
+	prev := []exchange.Market{
+		{Name: "BTCUSDT"},
+		{Name: "LUNAUSDT"},
+		{Name: "NEWUSDT", Status: exchange.StatusPreTrading},
+		{Name: "OLDUSDT"},
+	}
+	next := []exchange.Market{
+		{Name: "BTCUSDT"},
+		{Name: "LUNAUSDT", Status: exchange.ParseStatus("BREAK")},
+		{Name: "NEWUSDT", Status: exchange.ParseStatus("TRADING")},
+		{Name: "PEPEUSDT"},
+	}
+	expected := []exchange.StatusChange{
+		{Name: "LUNAUSDT", From: exchange.StatusTrading, To: exchange.StatusHalted},
+		{Name: "NEWUSDT", From: exchange.StatusPreTrading, To: exchange.StatusTrading},
+		{Name: "PEPEUSDT", From: exchange.StatusDelisted, To: exchange.StatusTrading},
+		{Name: "OLDUSDT", From: exchange.StatusTrading, To: exchange.StatusDelisted},
+	}
+	if res := exchange.StatusChanges(prev, next); !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
@@ -0,0 +1,221 @@
+package kraken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"github.com/goccy/go-json"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+const ID types.ExchangeID = 6
+const Name = "kraken"
+
+const baseURL = "https://api.kraken.com"
+const marketsPath = "/0/public/AssetPairs"
+const pricesPath = "/0/public/Ticker"
+const timePath = "/0/public/Time"
+
+// Limits of the public API, about a request per second per IP.
+var Limits = http.Limits{
+	Limits: []http.Limit{{Weight: 1, Window: time.Second}},
+}
+
+var governor = http.NewGovernor(Limits)
+
+// Kraken reports errors in the error array of the response, mostly with 200 OK.
+var (
+	parseErrors    = http.WithErrorParser(parseError)
+	envelopeErrors = http.WithEnvelopeCheck(parseError)
+)
+
+// pricesLevel enters the result object keyed by pair, past the error array: {"error":[],"result":{"XXBTZUSD":{...}}}
+const pricesLevel = 3
+
+// assetAliases maps Kraken asset codes to canonical names. Legacy codes are prefixed with X for crypto and Z for fiat.
+var assetAliases = map[string]string{
+	"XBT":  "BTC",
+	"XDG":  "DOGE",
+	"XXBT": "BTC",
+	"XXDG": "DOGE",
+	"XETH": "ETH",
+	"XETC": "ETC",
+	"XLTC": "LTC",
+	"XXRP": "XRP",
+	"XXLM": "XLM",
+	"XXMR": "XMR",
+	"XZEC": "ZEC",
+	"XMLN": "MLN",
+	"XREP": "REP",
+	"ZUSD": "USD",
+	"ZEUR": "EUR",
+	"ZGBP": "GBP",
+	"ZCAD": "CAD",
+	"ZJPY": "JPY",
+	"ZAUD": "AUD",
+	"ZCHF": "CHF",
+}
+
+// AssetName returns the canonical name of a Kraken asset code.
+func AssetName(code string) string {
+	if name, ok := assetAliases[code]; ok {
+		return name
+	}
+	return code
+}
+
+type bookPrices struct {
+	Pair string  `jetjson:"key"`
+	Bid  float64 `json:"b"`
+	Ask  float64 `json:"a"`
+}
+
+type assetPair struct {
+	Altname      string `json:"altname"`
+	Wsname       string `json:"wsname"`
+	Base         string `json:"base"`
+	Quote        string `json:"quote"`
+	PairDecimals int    `json:"pair_decimals"`
+	Status       string `json:"status"`
+}
+
+type serverTime struct {
+	Result struct {
+		Unixtime int64 `json:"unixtime"`
+	} `json:"result"`
+}
+
+type assetPairs struct {
+	Error  []string             `json:"error"`
+	Result map[string]assetPair `json:"result"`
+}
+
+func init() {
+	exchange.Register(Name, ID, func(opts ...exchange.Option) (exchange.Exchange, error) {
+		e, err := New(opts...)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	})
+}
+
+type Exchange struct {
+	assets         types.AssetMap
+	marketsFetcher http.CallFetch[assetPairs]
+	priceFetcher   http.CallIterate[bookPrices]
+	timeFetcher    http.CallFetch[serverTime]
+}
+
+// New creates the exchange, failing on a malformed base url of the options.
+func New(opts ...exchange.Option) (*Exchange, error) {
+	cfg := exchange.NewConfig(opts...)
+	cfg.Client = cfg.Govern(governor)
+	res := &Exchange{assets: cfg.Assets}
+	marketsTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, marketsPath), http.WithHeaders(http.WithCompression()), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.marketsFetcher = http.TemplateFetcher[assetPairs](cfg.Client, marketsTemplate)
+	pricesTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, pricesPath), http.WithHeaders(http.WithCompression()), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.priceFetcher = http.TemplateMapIterator[bookPrices](cfg.Client, pricesTemplate, pricesLevel)
+	timeTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, timePath), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.timeFetcher = http.TemplateFetcher[serverTime](cfg.Client, timeTemplate)
+	return res, nil
+}
+
+func (e *Exchange) ID() types.ExchangeID {
+	return ID
+}
+
+func (e *Exchange) Name() string {
+	return Name
+}
+
+func (e *Exchange) Prices(buf scrap.IPriceWriter) (err error) {
+	return e.priceFetcher(context.Background(), func(v bookPrices) error {
+		if v.Bid > 0. && v.Ask > 0. {
+			if err = buf.Write(v.Pair, v.Bid, v.Ask); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ServerTime returns the time of the exchange servers. Kraken publishes it in whole seconds,
+// so offsets sampled from it are accurate to a second at best.
+func (e *Exchange) ServerTime(ctx context.Context) (time.Time, error) {
+	var res serverTime
+	if err := e.timeFetcher(ctx, &res); err != nil {
+		return time.Time{}, err
+	}
+	if res.Result.Unixtime <= 0 {
+		return time.Time{}, errors.New("kraken: missing server time")
+	}
+	return time.Unix(res.Result.Unixtime, 0), nil
+}
+
+// Markets returns online pairs named as the ticker keys them, with canonical base and quote assets.
+func (e *Exchange) Markets(ctx context.Context) (res []exchange.Market, err error) {
+	var info assetPairs
+	if err = e.marketsFetcher(ctx, &info); err != nil {
+		return nil, err
+	}
+	if len(info.Error) > 0 {
+		return nil, fmt.Errorf("kraken: asset pairs error: %s", strings.Join(info.Error, ", "))
+	}
+
+	for name, p := range info.Result {
+		if p.Status != "online" {
+			continue
+		}
+		wsBase, wsQuote, ok := strings.Cut(p.Wsname, "/")
+		if !ok {
+			wsBase, wsQuote = p.Base, p.Quote
+		}
+		base, quote := AssetName(wsBase), AssetName(wsQuote)
+		if e.assets != nil {
+			// Aliases of unknown canonical assets are skipped
+			e.assets.Alias(p.Base, base)
+			e.assets.Alias(wsBase, base)
+			e.assets.Alias(p.Quote, quote)
+			e.assets.Alias(wsQuote, quote)
+		}
+		res = append(res, exchange.Market{
+			Name:      name,
+			Base:      base,
+			Quote:     quote,
+			Precision: math.Pow10(p.PairDecimals),
+		})
+	}
+	slices.SortFunc(res, func(a, b exchange.Market) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return
+}
+
+// parseError reads errors like {"error":["EQuery:Unknown asset pair"],"result":{}} into the code and message.
+func parseError(status int, body []byte) (code, message string, ok bool) {
+	var v struct {
+		Error []string `json:"error"`
+	}
+	if json.Unmarshal(body, &v) != nil || len(v.Error) == 0 {
+		return
+	}
+	code, message, _ = strings.Cut(v.Error[0], ":")
+	return code, message, true
+}
@@ -0,0 +1,104 @@
+package kraken_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"github.com/dk-open/crypto-zip/scrap/exchange/kraken"
+	"github.com/dk-open/crypto-zip/types"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testExchange(t *testing.T, opts ...exchange.Option) *kraken.Exchange {
+	return exchangetest.Exchange(t, map[string][]string{
+		"/0/public/AssetPairs": {"testdata/AssetPairs.json"},
+		"/0/public/Ticker":     {"testdata/Ticker.json"},
+	}, kraken.New, opts...)
+}
+
+func TestMarkets(t *testing.T) {
+	assets := types.AssetMap{}
+	assets.Add(1, "BTC")
+	assets.Add(2, "USD")
+	assets.Add(3, "DOGE")
+
+	markets, err := testExchange(t, exchange.WithAssets(assets)).Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.Market{
+		{Name: "SOLUSD", Base: "SOL", Quote: "USD", Precision: 1e2},
+		{Name: "XDGUSD", Base: "DOGE", Quote: "USD", Precision: 1e7},
+		{Name: "XETHXXBT", Base: "ETH", Quote: "BTC", Precision: 1e5},
+		{Name: "XETHZUSD", Base: "ETH", Quote: "USD", Precision: 1e2},
+		{Name: "XXBTZUSD", Base: "BTC", Quote: "USD", Precision: 1e1},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+
+	for alias, name := range map[string]string{"XXBT": "BTC", "XBT": "BTC", "ZUSD": "USD", "XXDG": "DOGE", "XDG": "DOGE"} {
+		if assets[alias] != assets[name] {
+			t.Errorf("expected %s to be an alias of %s", alias, name)
+		}
+	}
+	if _, ok := assets["XETH"]; ok {
+		t.Errorf("alias of an unknown asset must not be added")
+	}
+}
+
+func TestPrices(t *testing.T) {
+	res := exchangetest.Prices{}
+	if err := testExchange(t).Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected := exchangetest.Prices{
+		"XXBTZUSD": {68015.1, 68015.2},
+		"XETHZUSD": {2601.55, 2601.56},
+		"XDGUSD":   {0.13421, 0.13422},
+		"SOLUSD":   {148.12, 148.13},
+		"XETHXXBT": {0.03824, 0.03825},
+		"XREPZEUR": {0.512, 0.514},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	for code, name := range map[string]string{"XXBT": "BTC", "XBT": "BTC", "XDG": "DOGE", "ZEUR": "EUR", "SOL": "SOL"} {
+		if res := kraken.AssetName(code); res != name {
+			t.Errorf("%s: expected %s, got %s", code, name, res)
+		}
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/0/public/AssetPairs": {"testdata/error.json"},
+		"/0/public/Ticker":     {"testdata/error.json"},
+	})
+	e := http.Must(kraken.New(exchange.WithBaseURL(srv.URL)))
+	var se *http.StatusError
+	if _, err := e.Markets(context.Background()); !errors.As(err, &se) || se.Code != "EGeneral" {
+		t.Errorf("expected the envelope error, got %v", err)
+	}
+	if err := e.Prices(exchangetest.Prices{}); !errors.As(err, &se) || se.StatusCode != 200 || se.Message != "Too many requests" {
+		t.Errorf("expected the envelope error, got %v", err)
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{"/0/public/Time": {"testdata/time.json"}})
+	res, err := http.Must(kraken.New(exchange.WithBaseURL(srv.URL))).ServerTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal(time.Unix(1729339200, 0)) {
+		t.Errorf("unexpected server time %v", res)
+	}
+}
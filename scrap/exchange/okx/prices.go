@@ -0,0 +1,161 @@
+package okx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"strconv"
+	"time"
+)
+
+const ID types.ExchangeID = 3
+const Name = "okx"
+
+const baseURL = "https://www.okx.com"
+const marketsPath = "/api/v5/public/instruments?instType=SPOT"
+const pricesPath = "/api/v5/market/tickers?instType=SPOT"
+const timePath = "/api/v5/public/time"
+
+// Limits of the public endpoints, counted per IP and endpoint.
+var Limits = http.Limits{
+	Limits: []http.Limit{
+		{Weight: 20, Window: 2 * time.Second, Path: "/api/v5/public/instruments"},
+		{Weight: 20, Window: 2 * time.Second, Path: "/api/v5/market/tickers"},
+		{Weight: 10, Window: 2 * time.Second, Path: timePath},
+	},
+}
+
+var governor = http.NewGovernor(Limits)
+
+// parseErrors reads error bodies like {"code":"51001","msg":"Instrument ID does not exist"}
+var parseErrors = http.WithErrorParser(http.JSONErrorParser("code", "msg"))
+
+// envelopeErrors fails 200 responses with a non-zero code, the way most OKX errors are returned.
+var envelopeErrors = http.WithEnvelopeCheck(http.JSONEnvelopeParser("code", "msg", "0"))
+
+// pricesLevel skips the response object and enters its data array: {"code":"0","msg":"","data":[...]}
+const pricesLevel = 2
+
+type bookPrices struct {
+	Symbol string  `json:"instId"`
+	Bid    float64 `json:"bidPx"`
+	Ask    float64 `json:"askPx"`
+}
+
+type serverTime struct {
+	Data []struct {
+		Ts string `json:"ts"`
+	} `json:"data"`
+}
+
+type instruments struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		InstID   string `json:"instId"`
+		BaseCcy  string `json:"baseCcy"`
+		QuoteCcy string `json:"quoteCcy"`
+		TickSz   string `json:"tickSz"`
+		State    string `json:"state"`
+	} `json:"data"`
+}
+
+func init() {
+	exchange.Register(Name, ID, func(opts ...exchange.Option) (exchange.Exchange, error) {
+		e, err := New(opts...)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	})
+}
+
+type Exchange struct {
+	marketsFetcher http.CallFetch[instruments]
+	priceFetcher   http.CallIterate[bookPrices]
+	timeFetcher    http.CallFetch[serverTime]
+}
+
+// New creates the exchange, failing on a malformed base url of the options.
+func New(opts ...exchange.Option) (*Exchange, error) {
+	cfg := exchange.NewConfig(opts...)
+	cfg.Client = cfg.Govern(governor)
+	res := &Exchange{}
+	marketsTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, marketsPath), http.WithHeaders(http.WithCompression()), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.marketsFetcher = http.TemplateFetcher[instruments](cfg.Client, marketsTemplate)
+	pricesTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, pricesPath), http.WithHeaders(http.WithCompression()), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.priceFetcher = http.TemplateIterator[bookPrices](cfg.Client, pricesTemplate, pricesLevel)
+	timeTemplate, err := http.NewTemplate("GET", cfg.URL(baseURL, timePath), parseErrors, envelopeErrors)
+	if err != nil {
+		return nil, err
+	}
+	res.timeFetcher = http.TemplateFetcher[serverTime](cfg.Client, timeTemplate)
+	return res, nil
+}
+
+func (e *Exchange) ID() types.ExchangeID {
+	return ID
+}
+
+func (e *Exchange) Name() string {
+	return Name
+}
+
+func (e *Exchange) Prices(buf scrap.IPriceWriter) (err error) {
+	return e.priceFetcher(context.Background(), func(v bookPrices) error {
+		if v.Bid > 0. && v.Ask > 0. {
+			if err = buf.Write(v.Symbol, v.Bid, v.Ask); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ServerTime returns the time of the exchange servers.
+func (e *Exchange) ServerTime(ctx context.Context) (time.Time, error) {
+	var res serverTime
+	if err := e.timeFetcher(ctx, &res); err != nil {
+		return time.Time{}, err
+	}
+	if len(res.Data) == 0 {
+		return time.Time{}, errors.New("okx: missing server time")
+	}
+	ms, err := strconv.ParseInt(res.Data[0].Ts, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("okx: server time: %w", err)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+func (e *Exchange) Markets(ctx context.Context) (res []exchange.Market, err error) {
+	var info instruments
+	if err = e.marketsFetcher(ctx, &info); err != nil {
+		return nil, err
+	}
+	if info.Code != "0" {
+		return nil, fmt.Errorf("okx: instruments error %s: %s", info.Code, info.Msg)
+	}
+
+	for _, inst := range info.Data {
+		if inst.State == "live" {
+			res = append(res, exchange.Market{
+				Name:      inst.InstID,
+				Base:      inst.BaseCcy,
+				Quote:     inst.QuoteCcy,
+				Precision: exchange.TickPrecision(inst.TickSz),
+			})
+		}
+	}
+	return
+}
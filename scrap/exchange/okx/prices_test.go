@@ -0,0 +1,78 @@
+package okx_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"github.com/dk-open/crypto-zip/scrap/exchange/okx"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testExchange(t *testing.T) *okx.Exchange {
+	return exchangetest.Exchange(t, map[string][]string{
+		"/api/v5/public/instruments": {"testdata/instruments.json"},
+		"/api/v5/market/tickers":     {"testdata/tickers.json"},
+	}, okx.New)
+}
+
+func TestMarkets(t *testing.T) {
+	markets, err := testExchange(t).Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.Market{
+		{Name: "BTC-USDT", Base: "BTC", Quote: "USDT", Precision: 1e1},
+		{Name: "ETH-USDT", Base: "ETH", Quote: "USDT", Precision: 1e2},
+		{Name: "DOGE-USDT", Base: "DOGE", Quote: "USDT", Precision: 1e5},
+		{Name: "PEPE-USDT", Base: "PEPE", Quote: "USDT", Precision: 1e9},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+}
+
+func TestPrices(t *testing.T) {
+	res := exchangetest.Prices{}
+	if err := testExchange(t).Prices(res); err != nil {
+		t.Fatal(err)
+	}
+	expected := exchangetest.Prices{
+		"BTC-USDT":  {68012.3, 68012.4},
+		"ETH-USDT":  {2601.55, 2601.56},
+		"DOGE-USDT": {0.13421, 0.13422},
+		"PEPE-USDT": {0.000010011, 0.000010012},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v5/public/instruments": {"testdata/error.json"},
+		"/api/v5/market/tickers":     {"testdata/error.json"},
+	})
+	e := http.Must(okx.New(exchange.WithBaseURL(srv.URL)))
+	var se *http.StatusError
+	if _, err := e.Markets(context.Background()); !errors.As(err, &se) || se.Code != "50011" {
+		t.Errorf("expected the envelope error, got %v", err)
+	}
+	if err := e.Prices(exchangetest.Prices{}); !errors.As(err, &se) || se.StatusCode != 200 || se.Message != "Too Many Requests" {
+		t.Errorf("expected the envelope error, got %v", err)
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{"/api/v5/public/time": {"testdata/time.json"}})
+	res, err := http.Must(okx.New(exchange.WithBaseURL(srv.URL))).ServerTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal(time.UnixMilli(1729339200123)) {
+		t.Errorf("unexpected server time %v", res)
+	}
+}
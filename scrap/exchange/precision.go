@@ -0,0 +1,15 @@
+package exchange
+
+import (
+	"math"
+	"strings"
+)
+
+// TickPrecision returns the multiplier turning prices into integer ticks from a decimal tick size like "0.0100".
+func TickPrecision(tick string) float64 {
+	dot := strings.IndexByte(tick, '.')
+	if dot < 0 {
+		return 1
+	}
+	return math.Pow10(len(strings.TrimRight(tick[dot+1:], "0")))
+}
@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/types"
+	"sort"
+	"sync"
+)
+
+// Factory creates an exchange, failing on invalid options such as a malformed base url.
+type Factory func(opts ...Option) (Exchange, error)
+
+var registry = struct {
+	sync.RWMutex
+	factories map[string]Factory
+	ids       map[types.ExchangeID]string
+}{factories: map[string]Factory{}, ids: map[types.ExchangeID]string{}}
+
+// ErrRegistered is returned by TryRegister for a name or an id taken by another exchange.
+var ErrRegistered = errors.New("exchange: already registered")
+
+// Register makes the exchange available by name. It is called from init of the exchange package
+// and panics if the name or the id is already taken.
+func Register(name string, id types.ExchangeID, f Factory) {
+	if err := TryRegister(name, id, f); err != nil {
+		panic(err.Error())
+	}
+}
+
+// TryRegister makes the exchange available by name, failing with ErrRegistered if the name or the id
+// is already taken. It is meant for exchanges declared at run time, e.g. by config files.
+func TryRegister(name string, id types.ExchangeID, f Factory) error {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.factories[name]; ok {
+		return fmt.Errorf("%w: name %s", ErrRegistered, name)
+	}
+	if taken, ok := registry.ids[id]; ok {
+		return fmt.Errorf("%w: id %d of %s", ErrRegistered, id, taken)
+	}
+	registry.factories[name] = f
+	registry.ids[id] = name
+	return nil
+}
+
+// New instantiates the registered exchange, returning the error of its factory.
+func New(name string, opts ...Option) (Exchange, error) {
+	registry.RLock()
+	f, ok := registry.factories[name]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("exchange: unknown exchange %q", name)
+	}
+	return f(opts...)
+}
+
+// Names lists registered exchanges in alphabetical order.
+func Names() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	res := make([]string, 0, len(registry.factories))
+	for name := range registry.factories {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,30 @@
+package exchange_test
+
+import (
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	_ "github.com/dk-open/crypto-zip/scrap/exchange/all"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	ids := map[uint16]string{}
+	for _, name := range exchange.Names() {
+		ex, err := exchange.New(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ex.Name() != name {
+			t.Errorf("exchange %s registered as %s", ex.Name(), name)
+		}
+		if other, ok := ids[ex.ID().ID()]; ok {
+			t.Errorf("exchanges %s and %s share id %d", name, other, ex.ID())
+		}
+		ids[ex.ID().ID()] = name
+	}
+	if _, ok := ids[1]; !ok {
+		t.Errorf("binance is not registered: %v", exchange.Names())
+	}
+	if _, err := exchange.New("unknown"); err == nil {
+		t.Errorf("unknown exchange must not be instantiated")
+	}
+}
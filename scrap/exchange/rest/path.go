@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/goccy/go-json"
+	"strconv"
+	"strings"
+)
+
+// Path is a dotted path into a decoded JSON document like "result.list". A numeric segment indexes
+// an array, a segment with a [field=value] selector picks the first object of an array whose field
+// has the value, e.g. "filters[filterType=PRICE_FILTER].tickSize".
+type Path []segment
+
+type segment struct {
+	name string
+	// index of the array element, -1 if the segment is not numeric
+	index int
+	// field and value of the selector, field is empty without selector
+	field, value string
+}
+
+// ParsePath parses the dotted path, an empty string is the path of the document itself.
+func ParsePath(s string) (Path, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ".")
+	res := make(Path, 0, len(parts))
+	for _, part := range parts {
+		seg := segment{name: part, index: -1}
+		if open := strings.IndexByte(part, '['); open >= 0 {
+			eq := strings.IndexByte(part, '=')
+			if !strings.HasSuffix(part, "]") || eq < open {
+				return nil, fmt.Errorf("invalid selector in path %q", s)
+			}
+			seg.name, seg.field, seg.value = part[:open], part[open+1:eq], part[eq+1:len(part)-1]
+		} else if i, err := strconv.Atoi(part); err == nil && i >= 0 {
+			seg.index = i
+		}
+		if seg.name == "" && seg.field == "" {
+			return nil, fmt.Errorf("empty segment in path %q", s)
+		}
+		res = append(res, seg)
+	}
+	return res, nil
+}
+
+// Lookup returns the value at the path, false if any segment is missing.
+func (p Path) Lookup(v any) (any, bool) {
+	for _, seg := range p {
+		if seg.index >= 0 {
+			if arr, ok := v.([]any); ok {
+				if seg.index >= len(arr) {
+					return nil, false
+				}
+				v = arr[seg.index]
+				continue
+			}
+		}
+		if seg.name != "" {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = obj[seg.name]; !ok {
+				return nil, false
+			}
+		}
+		if seg.field != "" {
+			var ok bool
+			if v, ok = selectElement(v, seg.field, seg.value); !ok {
+				return nil, false
+			}
+		}
+	}
+	return v, true
+}
+
+// String returns the scalar at the path as a string, numbers keep their decimal representation.
+func (p Path) String(v any) (string, bool) {
+	v, ok := p.Lookup(v)
+	if !ok {
+		return "", false
+	}
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case json.Number:
+		return s.String(), true
+	case bool:
+		return strconv.FormatBool(s), true
+	}
+	return "", false
+}
+
+func selectElement(v any, field, value string) (any, bool) {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, false
+	}
+	selector := Path{{name: field, index: -1}}
+	for _, el := range arr {
+		if s, found := selector.String(el); found && s == value {
+			return el, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,188 @@
+// Package rest implements exchanges declared by a Spec instead of code, for venues publishing
+// a markets list and a book ticker list over plain REST.
+package rest
+
+import (
+	"context"
+	"fmt"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/types"
+	"github.com/goccy/go-json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// governors are shared by exchanges of the same name, as limits are counted per IP.
+var governors = struct {
+	sync.Mutex
+	byName map[string]*http.Governor
+}{byName: map[string]*http.Governor{}}
+
+// Register makes the exchange of the spec available by its name. A name or an id taken by another
+// exchange, built in or declared, fails with exchange.ErrRegistered.
+func Register(spec Spec) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	return exchange.TryRegister(spec.Name, spec.ID, func(opts ...exchange.Option) (exchange.Exchange, error) {
+		e, err := New(spec, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	})
+}
+
+type marketPaths struct {
+	list, symbol, base, quote, status, tickSize Path
+}
+
+type Exchange struct {
+	spec           Spec
+	paths          marketPaths
+	marketsFetcher http.FetcherReader
+	priceFetcher   http.IterateFetch[[]string]
+}
+
+// New creates the exchange of a valid spec, see Spec.Validate. It fails on a malformed base url of the spec or options.
+func New(spec Spec, opts ...exchange.Option) (*Exchange, error) {
+	cfg := exchange.NewConfig(opts...)
+	cfg.Client = cfg.Govern(governor(spec))
+	res := &Exchange{spec: spec}
+	var err error
+	if res.marketsFetcher, err = http.FetcherCustom(cfg.Client, "GET", cfg.URL(spec.BaseURL, spec.Markets.Path), http.WithCompression()); err != nil {
+		return nil, err
+	}
+	if res.priceFetcher, err = http.FieldsIteratorWithClient(cfg.Client, "GET", cfg.URL(spec.BaseURL, spec.Prices.Path), spec.Prices.Level,
+		[]string{spec.Prices.Symbol, spec.Prices.Bid, spec.Prices.Ask}, http.WithCompression()); err != nil {
+		return nil, err
+	}
+	// Paths are checked by Validate
+	res.paths.list, _ = ParsePath(spec.Markets.List)
+	res.paths.symbol, _ = ParsePath(spec.Markets.Symbol)
+	res.paths.base, _ = ParsePath(spec.Markets.Base)
+	res.paths.quote, _ = ParsePath(spec.Markets.Quote)
+	res.paths.status, _ = ParsePath(spec.Markets.Status)
+	res.paths.tickSize, _ = ParsePath(spec.Markets.TickSize)
+	return res, nil
+}
+
+func governor(spec Spec) *http.Governor {
+	governors.Lock()
+	defer governors.Unlock()
+	if g, ok := governors.byName[spec.Name]; ok {
+		return g
+	}
+	limits := http.Limits{DefaultCost: 1}
+	for _, l := range spec.Limits {
+		limits.Limits = append(limits.Limits, http.Limit{Weight: l.Weight, Window: time.Duration(l.Window)})
+	}
+	g := http.NewGovernor(limits)
+	governors.byName[spec.Name] = g
+	return g
+}
+
+func (e *Exchange) ID() types.ExchangeID {
+	return e.spec.ID
+}
+
+func (e *Exchange) Name() string {
+	return e.spec.Name
+}
+
+// Spec returns the spec the exchange is declared by.
+func (e *Exchange) Spec() Spec {
+	return e.spec
+}
+
+func (e *Exchange) Prices(buf scrap.IPriceWriter) (err error) {
+	return e.priceFetcher(func(v []string) error {
+		bid, bErr := strconv.ParseFloat(v[1], 64)
+		ask, aErr := strconv.ParseFloat(v[2], 64)
+		if bErr == nil && aErr == nil && bid > 0. && ask > 0. {
+			if err = buf.Write(v[0], bid, ask); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (e *Exchange) Markets(ctx context.Context) (res []exchange.Market, err error) {
+	var doc any
+	err = e.marketsFetcher(ctx, func(ctx context.Context, reader io.Reader) error {
+		dec := json.NewDecoder(reader)
+		// Numbers are kept as written, so tick sizes do not go through floats
+		dec.UseNumber()
+		return dec.Decode(&doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	list, ok := e.paths.list.Lookup(doc)
+	items, isArray := list.([]any)
+	if !ok || !isArray {
+		return nil, fmt.Errorf("rest: %s: no markets list at %q", e.spec.Name, e.spec.Markets.List)
+	}
+
+	for _, item := range items {
+		if m, ok := e.market(item); ok {
+			res = append(res, m)
+		}
+	}
+	return
+}
+
+// market reads a trading market from the item of the markets list.
+func (e *Exchange) market(item any) (m exchange.Market, ok bool) {
+	spec := e.spec.Markets
+	if m.Name, ok = e.paths.symbol.String(item); !ok || m.Name == "" {
+		return m, false
+	}
+	if spec.Status != "" {
+		status, _ := e.paths.status.String(item)
+		if !contains(spec.Trading, status) {
+			return m, false
+		}
+	}
+	if spec.Base != "" && spec.Quote != "" {
+		m.Base, _ = e.paths.base.String(item)
+		m.Quote, _ = e.paths.quote.String(item)
+	} else if base, quote, found := strings.Cut(m.Name, spec.Separator); found {
+		m.Base, m.Quote = base, quote
+	}
+	if m.Base == "" || m.Quote == "" {
+		return m, false
+	}
+
+	m.Precision = 1
+	if tick, found := e.paths.tickSize.String(item); found && spec.TickSize != "" {
+		switch spec.Precision {
+		case PrecisionDecimals:
+			if decimals, err := strconv.Atoi(tick); err == nil && decimals >= 0 {
+				m.Precision = math.Pow10(decimals)
+			}
+		default:
+			m.Precision = exchange.TickPrecision(tick)
+			if size, err := strconv.ParseFloat(tick, 64); err == nil {
+				m.PriceFilter.TickSize = size
+			}
+		}
+	}
+	return m, true
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
+	"github.com/dk-open/crypto-zip/scrap/exchange/binance"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
+	"github.com/dk-open/crypto-zip/scrap/exchange/rest"
+	"github.com/goccy/go-json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loadSpec(t *testing.T, path string) rest.Spec {
+	t.Helper()
+	spec, err := rest.LoadSpec(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestSpecMatchesAdapter(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v1/exchangeInfo":      {"../binance/testdata/exchangeInfo.json"},
+		"/api/v3/ticker/bookTicker": {"../binance/testdata/bookTicker.json"},
+	})
+	ctx := context.Background()
+	declared := http.Must(rest.New(loadSpec(t, "testdata/binance.yaml"), exchange.WithBaseURL(srv.URL)))
+	adapter := http.Must(binance.New(exchange.WithBaseURL(srv.URL)))
+	if declared.ID() != adapter.ID() || declared.Name() != adapter.Name() {
+		t.Fatalf("unexpected exchange %d %s", declared.ID(), declared.Name())
+	}
+
+	markets, err := declared.Markets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := adapter.Markets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The spec lists trading markets only
+	expected := exchange.Trading(listed)
+	if len(markets) != len(expected) {
+		t.Fatalf("expected %d markets, got %v", len(expected), markets)
+	}
+	for i, m := range markets {
+		e := expected[i]
+		if m.Name != e.Name || m.Base != e.Base || m.Quote != e.Quote || m.Precision != e.Precision || m.PriceFilter.TickSize != e.PriceFilter.TickSize {
+			t.Errorf("expected %v, got %v", e, m)
+		}
+	}
+
+	prices, expectedPrices := exchangetest.Prices{}, exchangetest.Prices{}
+	if err = declared.Prices(prices); err != nil {
+		t.Fatal(err)
+	}
+	if err = adapter.Prices(expectedPrices); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(prices, expectedPrices) {
+		t.Fatalf("expected %v, got %v", expectedPrices, prices)
+	}
+}
+
+func TestDecimalsPrecision(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v4/spot/currency_pairs": {"testdata/currency_pairs.json"},
+		"/api/v4/spot/tickers":        {"testdata/tickers.json"},
+	})
+	ex := http.Must(rest.New(loadSpec(t, "testdata/gateio.json"), exchange.WithBaseURL(srv.URL)))
+
+	markets, err := ex.Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []exchange.Market{
+		{Name: "BTC_USDT", Base: "BTC", Quote: "USDT", Precision: 1e1},
+		{Name: "ETH_USDT", Base: "ETH", Quote: "USDT", Precision: 1e2},
+		{Name: "PEPE_USDT", Base: "PEPE", Quote: "USDT", Precision: 1e11},
+	}
+	if !reflect.DeepEqual(markets, expected) {
+		t.Fatalf("expected %v, got %v", expected, markets)
+	}
+
+	prices := exchangetest.Prices{}
+	if err = ex.Prices(prices); err != nil {
+		t.Fatal(err)
+	}
+	expectedPrices := exchangetest.Prices{
+		"BTC_USDT":  {68012.1, 68012.2},
+		"ETH_USDT":  {2601.51, 2601.52},
+		"PEPE_USDT": {0.00000983987, 0.00000984123},
+	}
+	if !reflect.DeepEqual(prices, expectedPrices) {
+		t.Fatalf("expected %v, got %v", expectedPrices, prices)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	spec := loadSpec(t, "testdata/gateio.json")
+	spec.Name = "gateio-test"
+	if err := rest.Register(spec); err != nil {
+		t.Fatal(err)
+	}
+	ex, err := exchange.New("gateio-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex.ID() != 100 {
+		t.Errorf("unexpected exchange id %d", ex.ID())
+	}
+	if err = rest.Register(spec); !errors.Is(err, exchange.ErrRegistered) {
+		t.Errorf("expected the name taken, got %v", err)
+	}
+}
+
+func TestRegisterBuiltIn(t *testing.T) {
+	// The spec declares the name and the id of the built in binance adapter
+	spec := loadSpec(t, "testdata/binance.yaml")
+	if err := rest.Register(spec); !errors.Is(err, exchange.ErrRegistered) {
+		t.Errorf("expected the name taken, got %v", err)
+	}
+	spec.Name = "binance-rest"
+	if err := rest.Register(spec); !errors.Is(err, exchange.ErrRegistered) {
+		t.Errorf("expected the id taken, got %v", err)
+	}
+	if _, err := exchange.New("binance-rest"); err == nil {
+		t.Error("the spec of a taken id must not be registered")
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	valid := `{"id":1,"name":"x","baseUrl":"https://x","markets":{"path":"/m","symbol":"s","separator":"-"},"prices":{"path":"/p","symbol":"s","bid":"b","ask":"a"}}`
+	if _, err := rest.ParseSpec([]byte(valid)); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct{ from, to, message string }{
+		{`"id":1`, `"id":0`, "id is required"},
+		{`"separator":"-"`, `"base":"b"`, "markets.separator"},
+		{`"bid":"b"`, `"bid":""`, "prices.bid is required"},
+		{`"symbol":"s","separator"`, `"symbol":"s","precision":"ticks","separator"`, `unknown precision rule "ticks"`},
+		{`"symbol":"s","separator"`, `"symbol":"s","tickSize":"filters[PRICE_FILTER]","separator"`, "invalid selector"},
+		{`"baseUrl"`, `"limits":[{"weight":10,"window":"0s"}],"baseUrl"`, "invalid limit"},
+	} {
+		_, err := rest.ParseSpec([]byte(strings.Replace(valid, c.from, c.to, 1)))
+		if err == nil || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("expected error %q, got %v", c.message, err)
+		}
+	}
+}
+
+func TestPathLookup(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{"result":{"list":[{"symbol":"BTCUSDT","filters":[{"type":"LOT","tick":"1"},{"type":"PRICE","tick":"0.10"}]}]}}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range map[string]string{
+		"result.list.0.symbol":                   "BTCUSDT",
+		"result.list.0.filters[type=PRICE].tick": "0.10",
+		"result.list.0.filters.0.tick":           "1",
+		"result.list.1.symbol":                   "",
+		"result.list.0.filters[type=SIZE].tick":  "",
+	} {
+		p, err := rest.ParsePath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := p.String(doc); v != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, v)
+		}
+	}
+}
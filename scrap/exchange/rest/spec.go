@@ -0,0 +1,154 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dk-open/crypto-zip/types"
+	"gopkg.in/yaml.v3"
+	"os"
+	"time"
+)
+
+// PrecisionRule tells how the price precision of a market is derived from its tick size field.
+type PrecisionRule string
+
+const (
+	// PrecisionTick reads a decimal tick size like "0.0100".
+	PrecisionTick PrecisionRule = "tick"
+	// PrecisionDecimals reads the number of decimals of prices like 2.
+	PrecisionDecimals PrecisionRule = "decimals"
+)
+
+// Spec declares an exchange served by a REST API of a markets list and a book ticker list.
+// It is read from YAML or JSON.
+type Spec struct {
+	ID      types.ExchangeID `json:"id" yaml:"id"`
+	Name    string           `json:"name" yaml:"name"`
+	BaseURL string           `json:"baseUrl" yaml:"baseUrl"`
+	// Limits of the API, every request costs a single unit of weight.
+	Limits  []LimitSpec `json:"limits" yaml:"limits"`
+	Markets MarketsSpec `json:"markets" yaml:"markets"`
+	Prices  PricesSpec  `json:"prices" yaml:"prices"`
+}
+
+type LimitSpec struct {
+	Weight int      `json:"weight" yaml:"weight"`
+	Window Duration `json:"window" yaml:"window"`
+}
+
+// MarketsSpec locates markets in the response of the markets endpoint. Fields are paths relative
+// to a market, see Path for the syntax.
+type MarketsSpec struct {
+	// Path is the path and query of the endpoint.
+	Path string `json:"path" yaml:"path"`
+	// List is the path to the array of markets, empty when the response is the array.
+	List   string `json:"list" yaml:"list"`
+	Symbol string `json:"symbol" yaml:"symbol"`
+	Base   string `json:"base" yaml:"base"`
+	Quote  string `json:"quote" yaml:"quote"`
+	// Separator splits the symbol into the base and quote when they are not given, e.g. "_" for "BTC_USDT".
+	Separator string `json:"separator" yaml:"separator"`
+	// Status and Trading filter markets by the value of the status field. All markets are kept when Status is empty.
+	Status    string        `json:"status" yaml:"status"`
+	Trading   []string      `json:"trading" yaml:"trading"`
+	TickSize  string        `json:"tickSize" yaml:"tickSize"`
+	Precision PrecisionRule `json:"precision" yaml:"precision"`
+}
+
+// PricesSpec names fields of book tickers iterated at the JSON nesting level.
+type PricesSpec struct {
+	Path   string `json:"path" yaml:"path"`
+	Level  int    `json:"level" yaml:"level"`
+	Symbol string `json:"symbol" yaml:"symbol"`
+	Bid    string `json:"bid" yaml:"bid"`
+	Ask    string `json:"ask" yaml:"ask"`
+}
+
+// Duration is a time.Duration written as a string like "1m" or "500ms".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ParseSpec reads a spec from YAML, which covers JSON as well, and validates it.
+func ParseSpec(data []byte) (res Spec, err error) {
+	if err = yaml.Unmarshal(data, &res); err != nil {
+		return res, fmt.Errorf("rest: invalid spec: %w", err)
+	}
+	return res, res.Validate()
+}
+
+// LoadSpec reads the spec file.
+func LoadSpec(path string) (Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	return ParseSpec(data)
+}
+
+// Validate reports missing fields and invalid paths of the spec.
+func (s Spec) Validate() error {
+	var errs []error
+	required := func(name, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+	if s.ID == 0 {
+		errs = append(errs, errors.New("id is required"))
+	}
+	required("name", s.Name)
+	required("baseUrl", s.BaseURL)
+	required("markets.path", s.Markets.Path)
+	required("markets.symbol", s.Markets.Symbol)
+	required("prices.path", s.Prices.Path)
+	required("prices.symbol", s.Prices.Symbol)
+	required("prices.bid", s.Prices.Bid)
+	required("prices.ask", s.Prices.Ask)
+	if (s.Markets.Base == "" || s.Markets.Quote == "") && s.Markets.Separator == "" {
+		errs = append(errs, errors.New("markets.base and markets.quote or markets.separator are required"))
+	}
+	switch s.Markets.Precision {
+	case "", PrecisionTick, PrecisionDecimals:
+	default:
+		errs = append(errs, fmt.Errorf("unknown precision rule %q", s.Markets.Precision))
+	}
+	for _, p := range []string{s.Markets.List, s.Markets.Symbol, s.Markets.Base, s.Markets.Quote, s.Markets.Status, s.Markets.TickSize} {
+		if _, err := ParsePath(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, l := range s.Limits {
+		if l.Weight <= 0 || l.Window <= 0 {
+			errs = append(errs, fmt.Errorf("invalid limit of %d per %v", l.Weight, time.Duration(l.Window)))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("rest: spec %q: %w", s.Name, err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package scrap
 import (
 	"bytes"
 	"context"
+	"time"
 )
 
 type IScrapper interface {
@@ -12,3 +13,13 @@ type IScrapper interface {
 type IPriceWriter interface {
 	Write(name string, bid, ask float64) error
 }
+
+// IValueWriter receives a single value per market, e.g. a mark price or a funding rate.
+type IValueWriter interface {
+	Write(name string, value float64) error
+}
+
+// IClock estimates the offset of the exchange clock from the local one.
+type IClock interface {
+	Offset() time.Duration
+}
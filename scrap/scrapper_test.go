@@ -3,107 +3,149 @@ package scrap_test
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"encoding/json"
+	"github.com/dk-open/crypto-zip/http"
+	"github.com/dk-open/crypto-zip/scrap/exchange"
 	"github.com/dk-open/crypto-zip/scrap/exchange/binance"
 	"github.com/dk-open/crypto-zip/scrap/exchange/bitrue"
+	"github.com/dk-open/crypto-zip/scrap/exchange/exchangetest"
 	"github.com/dk-open/crypto-zip/scrap/smart"
 	"github.com/dk-open/crypto-zip/types"
+	"os"
+	"reflect"
+	"strconv"
 	"testing"
-	"time"
 )
 
-func TestScrapperBinance(t *testing.T) {
+// scrapTicks scraps the exchange once per served prices fixture and returns the book built from the frames.
+func scrapTicks(t *testing.T, ex exchange.Exchange, ticks int) *smart.Book {
+	t.Helper()
 	ctx := context.Background()
-	tm := time.Now()
 
-	markets, err := binance.Markets(ctx)
+	markets, err := ex.Markets(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
 	sMarkets := make(map[uint32]types.Market)
-
-	for i, m := range markets {
+	for i, m := range exchange.Trading(markets) {
 		sMarkets[uint32(i)] = types.Market{
 			Name:      m.Name,
 			Precision: m.Precision,
 		}
 	}
 
-	scrapper := smart.Scraper(sMarkets, binance.Prices)
+	scrapper := smart.Scraper(sMarkets, ex.Prices)
+	book := smart.NewBook(nil)
 
 	var buf bytes.Buffer
-	tm = time.Now()
-	if err = scrapper.Scrap(ctx, &buf); err != nil {
-		t.Error(err)
+	prevLen := 0
+	for i := 0; i < ticks; i++ {
+		buf.Reset()
+		if err = scrapper.Scrap(ctx, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && buf.Len() >= prevLen {
+			t.Errorf("diff frame %d is not smaller than the previous one: %d >= %d", i, buf.Len(), prevLen)
+		}
+		prevLen = buf.Len()
+		if err = book.ApplyBytes(buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
 	}
-	fmt.Println("Took", time.Since(tm).String(), buf.Len())
+	return book
+}
 
-	time.Sleep(1000 * time.Millisecond)
-	buf.Reset()
-	tm = time.Now()
-	if err = scrapper.Scrap(ctx, &buf); err != nil {
-		t.Error(err)
+func TestScrapperBinance(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v1/exchangeInfo":      {"exchange/binance/testdata/exchangeInfo.json"},
+		"/api/v3/ticker/bookTicker": {"exchange/binance/testdata/bookTicker.json", "exchange/binance/testdata/bookTicker2.json"},
+	})
+	book := scrapTicks(t, http.Must(binance.New(exchange.WithBaseURL(srv.URL))), 2)
+
+	expected := map[uint32]types.Price{
+		0: {0.03606, 0.03607},
+		1: {68015.55, 68015.56},
+		2: {0.5421, 0.5422},
+		3: {0.00001812, 0.00001814},
+	}
+	if snapshot := book.Snapshot(); !reflect.DeepEqual(snapshot, expected) {
+		t.Fatalf("expected %v, got %v", expected, snapshot)
 	}
-	fmt.Println("Took", time.Since(tm).String(), buf.Len())
+}
 
-	time.Sleep(1000 * time.Millisecond)
-	buf.Reset()
-	tm = time.Now()
-	if err = scrapper.Scrap(ctx, &buf); err != nil {
-		t.Error(err)
+func TestScrapperBitrue(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/api/v1/exchangeInfo": {"exchange/bitrue/testdata/exchangeInfo.json"},
+		"/api/v1/ticker/24hr":  {"exchange/bitrue/testdata/ticker24hr.json", "exchange/bitrue/testdata/ticker24hr2.json"},
+	})
+	book := scrapTicks(t, http.Must(bitrue.New(exchange.WithBaseURL(srv.URL))), 2)
+
+	if bid, ask, _ := book.Get(0); bid != 68020.13 || ask != 68020.99 {
+		t.Errorf("unexpected BTCUSDT prices %v %v", bid, ask)
 	}
-	fmt.Println("Took", time.Since(tm).String(), buf.Len())
+	assertRoundTrip(t, book, "exchange/bitrue/testdata/ticker24hr2.json")
+}
 
-	time.Sleep(3000 * time.Millisecond)
-	buf.Reset()
-	tm = time.Now()
-	if err = scrapper.Scrap(ctx, &buf); err != nil {
-		t.Error(err)
+// assertRoundTrip checks that every market of the book holds exactly the prices of the last served fixture.
+func assertRoundTrip(t *testing.T, book *smart.Book, fixture string) {
+	t.Helper()
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tickers []struct {
+		Symbol string `json:"symbol"`
+		Bid    string `json:"bidPrice"`
+		Ask    string `json:"askPrice"`
+	}
+	if err = json.Unmarshal(data, &tickers); err != nil {
+		t.Fatal(err)
+	}
+	expected := make(map[string]types.Price, len(tickers))
+	for _, v := range tickers {
+		bid, _ := strconv.ParseFloat(v.Bid, 64)
+		ask, _ := strconv.ParseFloat(v.Ask, 64)
+		expected[v.Symbol] = types.Price{bid, ask}
 	}
-	fmt.Println("Took", time.Since(tm).String(), buf.Len())
 
-	time.Sleep(5000 * time.Millisecond)
-	buf.Reset()
-	tm = time.Now()
-	if err = scrapper.Scrap(ctx, &buf); err != nil {
-		t.Error(err)
+	for _, m := range book.Table().Markets {
+		price, ok := expected[m.Name]
+		if !ok {
+			continue
+		}
+		if bid, ask, _ := book.Get(m.ID); bid != price[0] || ask != price[1] {
+			t.Errorf("%s with precision %v: expected %v, got %v %v", m.Name, m.Precision, price, bid, ask)
+		}
 	}
-	fmt.Println("Took", time.Since(tm).String(), buf.Len())
 }
 
-func TestScrapperBitrue(t *testing.T) {
-	ctx := context.Background()
-	tm := time.Now()
-
-	markets, err := bitrue.Markets(ctx)
+func TestScrapperBinanceFunding(t *testing.T) {
+	srv := exchangetest.Server(t, map[string][]string{
+		"/fapi/v1/exchangeInfo": {"exchange/binance/testdata/fapiExchangeInfo.json"},
+		"/fapi/v1/premiumIndex": {"exchange/binance/testdata/premiumIndex.json"},
+	})
+	ex := http.Must(binance.NewFutures(exchange.WithBaseURL(srv.URL)))
+	markets, err := ex.Markets(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
 	sMarkets := make(map[uint32]types.Market)
-
 	for i, m := range markets {
-		sMarkets[uint32(i)] = types.Market{
-			Name:      m.Name,
-			Precision: m.Precision,
+		if m.Type == exchange.Perpetual {
+			sMarkets[uint32(i)] = types.Market{Name: m.Name, Precision: binance.FundingPrecision}
 		}
 	}
-	fmt.Println(len(sMarkets))
-
-	scrapper := smart.Scraper(sMarkets, bitrue.Prices)
 
 	var buf bytes.Buffer
-	tm = time.Now()
-	if err = scrapper.Scrap(ctx, &buf); err != nil {
-		t.Error(err)
+	if err = smart.ValueScraper(sMarkets, ex.FundingRates).Scrap(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	book := smart.NewBook(nil)
+	if err = book.ApplyBytes(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[uint32]float64{0: 0.0001, 1: -0.00002341, 3: 0}
+	if values := book.Values(); !reflect.DeepEqual(values, expected) {
+		t.Fatalf("expected %v, got %v", expected, values)
 	}
-	fmt.Println("Took", time.Since(tm).String(), buf.Len())
-
-	time.Sleep(1000 * time.Millisecond)
-	buf.Reset()
-	tm = time.Now()
-	if err = scrapper.Scrap(ctx, &buf); err != nil {
-		t.Error(err)
-	}
-	//bbb := buf.Bytes()
-	fmt.Println("Took", time.Since(tm).String(), buf.Len())
 }
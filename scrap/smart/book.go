@@ -0,0 +1,203 @@
+package smart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/dk-open/crypto-zip/types"
+	"io"
+	"time"
+)
+
+type bookMarket struct {
+	id        uint32
+	precision float64
+	bid       uint64
+	ask       uint64
+	// value is the quantized value of markets of value series
+	value int64
+	ts    int64
+	// exchangeTs is the frame time on the exchange clock, equal to ts for frames without the clock offset
+	exchangeTs int64
+	valid      bool
+}
+
+// Book materialises the state of all markets from a stream of records written by Scraper or ValueScraper.
+type Book struct {
+	table   *MarketTable
+	markets []*bookMarket
+	byID    map[uint32]*bookMarket
+	keyed   bool
+	// positions decoded from the current frame index, reused between frames
+	positions []uint32
+}
+
+// NewBook creates a book. The table may be nil when the stream starts with a table record.
+func NewBook(table *MarketTable) *Book {
+	res := &Book{}
+	if table != nil {
+		res.setTable(table)
+	}
+	return res
+}
+
+// Table returns the market table the latest frames were written against.
+func (b *Book) Table() *MarketTable {
+	return b.table
+}
+
+func (b *Book) setTable(t *MarketTable) {
+	b.table = t
+	b.markets = make([]*bookMarket, 0, len(t.Markets))
+	b.byID = make(map[uint32]*bookMarket, len(t.Markets))
+	for _, m := range t.Markets {
+		bm := &bookMarket{id: m.ID, precision: m.Precision}
+		b.markets = append(b.markets, bm)
+		b.byID[m.ID] = bm
+	}
+	b.keyed = false
+}
+
+// Apply reads a single record and applies it to the book.
+func (b *Book) Apply(r io.ByteReader) error {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if kind == RecordTable {
+		t, tErr := readTable(r)
+		if tErr != nil {
+			return tErr
+		}
+		if b.table == nil || b.table.Version != t.Version || b.table.Hash != t.Hash {
+			b.setTable(t)
+		}
+		return nil
+	}
+
+	h, err := readFrameHeader(r, kind)
+	if err != nil {
+		return err
+	}
+	if !h.key() && !b.keyed {
+		return ErrNoKeyFrame
+	}
+	if b.table == nil || b.table.Version != h.version {
+		return ErrTableMismatch
+	}
+	if h.key() {
+		for _, m := range b.markets {
+			m.valid = false
+		}
+	}
+
+	if b.positions, err = readIndex(r, h.count, len(b.markets), b.positions[:0]); err != nil {
+		return err
+	}
+	for _, index := range b.positions {
+		if int(index) >= len(b.markets) {
+			return ErrInvalidFrame
+		}
+		m := b.markets[index]
+		if h.values() {
+			if m.value, err = binary.ReadVarint(r); err != nil {
+				return unexpectedEOF(err)
+			}
+			m.ts, m.exchangeTs, m.valid = h.ts, h.exchangeTime(), true
+			continue
+		}
+		var bid, askDiff uint64
+		if bid, err = binary.ReadUvarint(r); err != nil {
+			return unexpectedEOF(err)
+		}
+		if askDiff, err = binary.ReadUvarint(r); err != nil {
+			return unexpectedEOF(err)
+		}
+		m.bid, m.ask, m.ts, m.exchangeTs, m.valid = bid, bid+askDiff, h.ts, h.exchangeTime(), true
+	}
+	if h.key() {
+		b.keyed = true
+	}
+	return nil
+}
+
+// ApplyAll applies records until the reader is exhausted.
+func (b *Book) ApplyAll(r io.ByteReader) error {
+	for {
+		if err := b.Apply(r); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// ApplyBytes applies all records contained in data.
+func (b *Book) ApplyBytes(data []byte) error {
+	return b.ApplyAll(bytes.NewReader(data))
+}
+
+// Get returns the latest prices of the market and the time of the frame they came with.
+// Zero values are returned for unknown or not yet priced markets.
+func (b *Book) Get(marketID uint32) (bid, ask float64, ts time.Time) {
+	m, ok := b.byID[marketID]
+	if !ok || !m.valid {
+		return
+	}
+	p := m.price()
+	return p[0], p[1], time.UnixMilli(m.ts)
+}
+
+// Times returns the local and the exchange clock times of the latest frame of the market.
+// The exchange time equals the local one for frames scrapped without an exchange clock.
+func (b *Book) Times(marketID uint32) (local, exchange time.Time) {
+	m, ok := b.byID[marketID]
+	if !ok || !m.valid {
+		return
+	}
+	return time.UnixMilli(m.ts), time.UnixMilli(m.exchangeTs)
+}
+
+// Value returns the latest value of the market of a value series and the time of the frame it came with.
+// Zero values are returned for unknown or not yet valued markets.
+func (b *Book) Value(marketID uint32) (value float64, ts time.Time) {
+	m, ok := b.byID[marketID]
+	if !ok || !m.valid {
+		return
+	}
+	return float64(m.value) / m.precision, time.UnixMilli(m.ts)
+}
+
+// Values returns values of all valued markets of a value series.
+func (b *Book) Values() map[uint32]float64 {
+	res := make(map[uint32]float64, len(b.markets))
+	for _, m := range b.markets {
+		if m.valid {
+			res[m.id] = float64(m.value) / m.precision
+		}
+	}
+	return res
+}
+
+// Iterate calls f for every priced market in ascending market id order until f returns false.
+func (b *Book) Iterate(f func(marketID uint32, price types.Price, ts time.Time) bool) {
+	for _, m := range b.markets {
+		if m.valid && !f(m.id, m.price(), time.UnixMilli(m.ts)) {
+			return
+		}
+	}
+}
+
+// Snapshot returns prices of all priced markets.
+func (b *Book) Snapshot() map[uint32]types.Price {
+	res := make(map[uint32]types.Price, len(b.markets))
+	b.Iterate(func(marketID uint32, price types.Price, ts time.Time) bool {
+		res[marketID] = price
+		return true
+	})
+	return res
+}
+
+func (m *bookMarket) price() types.Price {
+	return types.Price{float64(m.bid) / m.precision, float64(m.ask) / m.precision}
+}
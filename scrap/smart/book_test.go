@@ -0,0 +1,128 @@
+package smart_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/scrap/smart"
+	"github.com/dk-open/crypto-zip/types"
+	"testing"
+	"time"
+)
+
+func TestBookAppliesFrames(t *testing.T) {
+	ctx := context.Background()
+	start := time.UnixMilli(1_700_000_000_000)
+	now := start
+	p := &fakeProducer{ticks: [][]quote{
+		{{"BTCUSDT", 60000.01, 60000.02}, {"ETHUSDT", 2500.5, 2500.6}},
+		{{"ETHUSDT", 2501, 2501.2}},
+		{{"XRPUSDT", 0.5123, 0.5124}},
+	}}
+	s := smart.Scraper(testMarkets(), p.Prices, smart.WithClock(func() time.Time { return now }))
+
+	var stream bytes.Buffer
+	for range p.ticks {
+		if err := s.Scrap(ctx, &stream); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Second)
+	}
+
+	book := smart.NewBook(nil)
+	if err := book.ApplyBytes(stream.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[uint32]types.Price{
+		1: {60000.01, 60000.02},
+		2: {2501, 2501.2},
+		3: {0.5123, 0.5124},
+	}
+	snapshot := book.Snapshot()
+	if len(snapshot) != len(expected) {
+		t.Fatalf("unexpected snapshot %v", snapshot)
+	}
+	for id, price := range expected {
+		if snapshot[id] != price {
+			t.Errorf("market %d: expected %v, got %v", id, price, snapshot[id])
+		}
+	}
+
+	if bid, ask, ts := book.Get(2); bid != 2501 || ask != 2501.2 || !ts.Equal(start.Add(time.Second)) {
+		t.Errorf("unexpected market 2 state %v %v %v", bid, ask, ts)
+	}
+	if _, _, ts := book.Get(42); !ts.IsZero() {
+		t.Errorf("unknown market must not be priced")
+	}
+}
+
+func TestBookRequiresKeyFrame(t *testing.T) {
+	ctx := context.Background()
+	p := &fakeProducer{ticks: [][]quote{
+		{{"BTCUSDT", 60000.01, 60000.02}},
+		{{"BTCUSDT", 60001, 60002}},
+	}}
+	s := smart.Scraper(testMarkets(), p.Prices)
+
+	var key, diff bytes.Buffer
+	if err := s.Scrap(ctx, &key); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Scrap(ctx, &diff); err != nil {
+		t.Fatal(err)
+	}
+
+	book := smart.NewBook(nil)
+	if err := book.ApplyBytes(diff.Bytes()); !errors.Is(err, smart.ErrNoKeyFrame) {
+		t.Fatalf("expected ErrNoKeyFrame, got %v", err)
+	}
+	if err := book.ApplyBytes(append(key.Bytes(), diff.Bytes()...)); err != nil {
+		t.Fatal(err)
+	}
+	if bid, ask, _ := book.Get(1); bid != 60001 || ask != 60002 {
+		t.Errorf("unexpected market 1 state %v %v", bid, ask)
+	}
+}
+
+type fixedClock time.Duration
+
+func (c fixedClock) Offset() time.Duration {
+	return time.Duration(c)
+}
+
+func TestBookExchangeTimes(t *testing.T) {
+	ctx := context.Background()
+	now := time.UnixMilli(1_700_000_000_000)
+	p := &fakeProducer{ticks: [][]quote{{{"BTCUSDT", 60000.01, 60000.02}}}}
+	clocked := smart.Scraper(testMarkets(), p.Prices, smart.WithClock(func() time.Time { return now }), smart.WithExchangeClock(fixedClock(-1250*time.Millisecond)))
+	var stream bytes.Buffer
+	if err := clocked.Scrap(ctx, &stream); err != nil {
+		t.Fatal(err)
+	}
+	book := smart.NewBook(nil)
+	if err := book.ApplyBytes(stream.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	local, exchange := book.Times(1)
+	if !local.Equal(now) || !exchange.Equal(now.Add(-1250*time.Millisecond)) {
+		t.Errorf("unexpected times %v and %v", local, exchange)
+	}
+	if _, _, ts := book.Get(1); !ts.Equal(now) {
+		t.Errorf("expected the local time, got %v", ts)
+	}
+
+	// Frames without the clock keep the exchange time equal to the local one
+	p = &fakeProducer{ticks: [][]quote{{{"BTCUSDT", 60000.01, 60000.02}}}}
+	stream.Reset()
+	if err := smart.Scraper(testMarkets(), p.Prices, smart.WithClock(func() time.Time { return now })).Scrap(ctx, &stream); err != nil {
+		t.Fatal(err)
+	}
+	book = smart.NewBook(nil)
+	if err := book.ApplyBytes(stream.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if local, exchange = book.Times(1); !local.Equal(now) || !exchange.Equal(now) {
+		t.Errorf("unexpected times %v and %v", local, exchange)
+	}
+}
@@ -0,0 +1,92 @@
+package smart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/types"
+	"time"
+)
+
+// FrameEntry is a decoded entry of a frame exposed to tests.
+type FrameEntry struct {
+	Index   uint32
+	Bid     uint64
+	AskDiff uint64
+}
+
+// DecodeFrame decodes a single frame scrapped from n markets, optionally preceded by the table record.
+func DecodeFrame(data []byte, n int) (kind, tag byte, entries []FrameEntry, err error) {
+	r := bytes.NewReader(data)
+	if kind, err = r.ReadByte(); err != nil {
+		return
+	}
+	if kind == RecordTable {
+		if _, err = readTable(r); err != nil {
+			return
+		}
+		if kind, err = r.ReadByte(); err != nil {
+			return
+		}
+	}
+	h, err := readFrameHeader(r, kind)
+	if err != nil {
+		return
+	}
+	if tag, err = r.ReadByte(); err != nil {
+		return
+	}
+	_ = r.UnreadByte()
+
+	positions, err := readIndex(r, h.count, n, nil)
+	if err != nil {
+		return
+	}
+	for _, p := range positions {
+		e := FrameEntry{Index: p}
+		if e.Bid, err = binary.ReadUvarint(r); err != nil {
+			return
+		}
+		if e.AskDiff, err = binary.ReadUvarint(r); err != nil {
+			return
+		}
+		entries = append(entries, e)
+	}
+	if r.Len() > 0 {
+		err = ErrInvalidFrame
+	}
+	return h.kind, tag, entries, err
+}
+
+var ChooseIndex = chooseIndex
+
+// EncodeIndex encodes the positions out of n markets with the given index encoding.
+func EncodeIndex(tag byte, positions []uint32, n int) []byte {
+	var buf bytes.Buffer
+	_ = writeIndex(&buf, tag, positions, n)
+	return buf.Bytes()
+}
+
+// DecodeIndex decodes count positions out of n markets.
+func DecodeIndex(data []byte, count uint64, n int) ([]uint32, error) {
+	return readIndex(bytes.NewReader(data), count, n, nil)
+}
+
+// Emitted is the last emitted state of a market exposed to tests.
+type Emitted struct {
+	Bid, Ask uint64
+	At       time.Time
+}
+
+// ClockWriter returns a price writer of the markets keyed by name with the given clock,
+// and the last emitted state of a market.
+func ClockWriter(markets map[string]types.Market, now func() time.Time) (scrap.IPriceWriter, func(name string) Emitted) {
+	prices := make(map[string]*marketPrice, len(markets))
+	for name, m := range markets {
+		prices[name] = &marketPrice{precision: m.Precision, minMove: m.MinMove, maxSilence: m.MaxSilence}
+	}
+	return &priceWriter{markets: prices, now: now}, func(name string) Emitted {
+		mp := prices[name]
+		return Emitted{Bid: mp.bid, Ask: mp.ask, At: mp.emitted}
+	}
+}
@@ -0,0 +1,109 @@
+package smart
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/dk-open/crypto-zip/compress"
+	"io"
+)
+
+// Frame kinds written at the start of every frame.
+const (
+	// FrameKey carries the full state of all priced markets and resets the reader.
+	FrameKey byte = 1
+	// FrameDiff carries only the markets updated since the previous frame.
+	FrameDiff byte = 2
+	// FrameValueKey and FrameValueDiff are key and diff frames of value series written by ValueScraper.
+	// Every entry carries a single signed value instead of the bid and ask pair.
+	FrameValueKey  byte = 4
+	FrameValueDiff byte = 5
+	// FrameClock is set on the kind of frames stamped with the exchange clock as well.
+	// The offset of the exchange time from the local one follows the timestamp as zigzag milliseconds.
+	FrameClock byte = 0x80
+)
+
+var ErrInvalidFrame = errors.New("smart: invalid frame")
+var ErrNoKeyFrame = errors.New("smart: diff frame before key frame")
+
+type frameHeader struct {
+	kind    byte
+	version uint32
+	ts      int64
+	// offset is the exchange clock offset in milliseconds, valid when clocked is set
+	offset  int64
+	clocked bool
+	count   uint64
+}
+
+// writeFrameHeader encodes kind, market table version, unix milliseconds timestamp, the optional
+// exchange clock offset and the number of entries in the frame.
+func writeFrameHeader(buf io.ByteWriter, h frameHeader) error {
+	kind := h.kind
+	if h.clocked {
+		kind |= FrameClock
+	}
+	if err := buf.WriteByte(kind); err != nil {
+		return err
+	}
+	if err := compress.WriteVariant(buf, uint64(h.version)); err != nil {
+		return err
+	}
+	if err := compress.WriteVariant(buf, uint64(h.ts)); err != nil {
+		return err
+	}
+	if h.clocked {
+		if err := compress.WriteVariant(buf, zigzag(h.offset)); err != nil {
+			return err
+		}
+	}
+	return compress.WriteVariant(buf, h.count)
+}
+
+// readFrameHeader reads the header following the kind byte.
+func readFrameHeader(r io.ByteReader, kind byte) (h frameHeader, err error) {
+	h.kind, h.clocked = kind&^FrameClock, kind&FrameClock != 0
+	switch h.kind {
+	case FrameKey, FrameDiff, FrameValueKey, FrameValueDiff:
+	default:
+		return h, ErrInvalidFrame
+	}
+	var version, ts uint64
+	if version, err = binary.ReadUvarint(r); err != nil {
+		return h, unexpectedEOF(err)
+	}
+	h.version = uint32(version)
+	if ts, err = binary.ReadUvarint(r); err != nil {
+		return h, unexpectedEOF(err)
+	}
+	h.ts = int64(ts)
+	if h.clocked {
+		if h.offset, err = binary.ReadVarint(r); err != nil {
+			return h, unexpectedEOF(err)
+		}
+	}
+	if h.count, err = binary.ReadUvarint(r); err != nil {
+		return h, unexpectedEOF(err)
+	}
+	return
+}
+
+func (h frameHeader) key() bool {
+	return h.kind == FrameKey || h.kind == FrameValueKey
+}
+
+func (h frameHeader) values() bool {
+	return h.kind == FrameValueKey || h.kind == FrameValueDiff
+}
+
+// exchangeTime returns the exchange clock timestamp, the local one for frames without the offset.
+func (h frameHeader) exchangeTime() int64 {
+	return h.ts + h.offset
+}
+
+// unexpectedEOF reports a frame truncated after its first byte.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,242 @@
+package smart
+
+import (
+	"encoding/binary"
+	"github.com/dk-open/crypto-zip/compress"
+	"io"
+	"math/bits"
+)
+
+// Index encodings of updated market positions, recorded in the tag byte of every frame.
+const (
+	// IndexSkip encodes every position as the number of skipped markets since the previous one.
+	IndexSkip byte = 0
+	// IndexBitmap encodes one bit per market of the table.
+	IndexBitmap byte = 1
+	// IndexRoaring splits positions by their high 16 bits into containers holding
+	// either absolute low 16 bits or a 2^16 bits bitmap, whichever is smaller.
+	IndexRoaring byte = 2
+)
+
+const roaringArrayMax = 4096
+const roaringBitmapSize = 1 << 16 / 8
+
+// chooseIndex picks the cheapest encoding of the sorted positions out of n markets.
+func chooseIndex(positions []uint32, n int) (tag byte) {
+	best := skipIndexSize(positions)
+	if size := (n + 7) / 8; size < best {
+		tag, best = IndexBitmap, size
+	}
+	if size := roaringIndexSize(positions); size < best {
+		tag = IndexRoaring
+	}
+	return
+}
+
+func skipIndexSize(positions []uint32) (res int) {
+	var prev uint32
+	for i, p := range positions {
+		if i > 0 {
+			p -= prev + 1
+		}
+		res += variantLen(uint64(p))
+		prev = positions[i]
+	}
+	return
+}
+
+func roaringIndexSize(positions []uint32) (res int) {
+	var containers uint64
+	forEachContainer(positions, func(key uint32, low []uint32) {
+		containers++
+		res += variantLen(uint64(key)) + variantLen(uint64(len(low)-1)) + roaringContainerSize(len(low))
+	})
+	return res + variantLen(containers)
+}
+
+func roaringContainerSize(card int) int {
+	if card > roaringArrayMax {
+		return roaringBitmapSize
+	}
+	return 2 * card
+}
+
+// forEachContainer groups the sorted positions by their high 16 bits.
+func forEachContainer(positions []uint32, f func(key uint32, group []uint32)) {
+	for start := 0; start < len(positions); {
+		key := positions[start] >> 16
+		end := start + 1
+		for end < len(positions) && positions[end]>>16 == key {
+			end++
+		}
+		f(key, positions[start:end])
+		start = end
+	}
+}
+
+func variantLen(x uint64) int {
+	return (bits.Len64(x|1) + 6) / 7
+}
+
+// writeIndex writes the tag byte followed by the encoded positions out of n markets.
+func writeIndex(buf io.ByteWriter, tag byte, positions []uint32, n int) error {
+	if err := buf.WriteByte(tag); err != nil {
+		return err
+	}
+	switch tag {
+	case IndexBitmap:
+		return writeBitmap(buf, positions, 0, n)
+	case IndexRoaring:
+		return writeRoaring(buf, positions)
+	default:
+		var prev uint32
+		for i, p := range positions {
+			if i > 0 {
+				p -= prev + 1
+			}
+			if err := compress.WriteVariant(buf, uint64(p)); err != nil {
+				return err
+			}
+			prev = positions[i]
+		}
+		return nil
+	}
+}
+
+// writeBitmap writes (n+7)/8 bytes with bits set for positions relative to the base.
+func writeBitmap(buf io.ByteWriter, positions []uint32, base uint32, n int) error {
+	var cur byte
+	var idx int
+	for i := 0; i < (n+7)/8; i++ {
+		cur = 0
+		for idx < len(positions) && int(positions[idx]-base)>>3 == i {
+			cur |= 1 << ((positions[idx] - base) & 7)
+			idx++
+		}
+		if err := buf.WriteByte(cur); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeRoaring(buf io.ByteWriter, positions []uint32) (err error) {
+	var containers uint64
+	forEachContainer(positions, func(uint32, []uint32) { containers++ })
+	if err = compress.WriteVariant(buf, containers); err != nil {
+		return err
+	}
+	forEachContainer(positions, func(key uint32, group []uint32) {
+		if err != nil {
+			return
+		}
+		if err = compress.WriteVariant(buf, uint64(key)); err != nil {
+			return
+		}
+		if err = compress.WriteVariant(buf, uint64(len(group)-1)); err != nil {
+			return
+		}
+		if len(group) > roaringArrayMax {
+			err = writeBitmap(buf, group, key<<16, 1<<16)
+			return
+		}
+		for _, p := range group {
+			if err = buf.WriteByte(byte(p)); err != nil {
+				return
+			}
+			if err = buf.WriteByte(byte(p >> 8)); err != nil {
+				return
+			}
+		}
+	})
+	return
+}
+
+// readIndex reads the tag byte and appends count decoded positions out of n markets to res.
+func readIndex(r io.ByteReader, count uint64, n int, res []uint32) ([]uint32, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return res, unexpectedEOF(err)
+	}
+	switch tag {
+	case IndexSkip:
+		var next uint64
+		for i := uint64(0); i < count; i++ {
+			skip, vErr := binary.ReadUvarint(r)
+			if vErr != nil {
+				return res, unexpectedEOF(vErr)
+			}
+			next += skip
+			res = append(res, uint32(next))
+			next++
+		}
+	case IndexBitmap:
+		if res, err = readBitmap(r, 0, n, res); err != nil {
+			return res, err
+		}
+	case IndexRoaring:
+		if res, err = readRoaring(r, res); err != nil {
+			return res, err
+		}
+	default:
+		return res, ErrInvalidFrame
+	}
+	if uint64(len(res)) != count {
+		return res, ErrInvalidFrame
+	}
+	return res, nil
+}
+
+func readBitmap(r io.ByteReader, base uint32, n int, res []uint32) ([]uint32, error) {
+	for i := 0; i < (n+7)/8; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return res, unexpectedEOF(err)
+		}
+		for b != 0 {
+			bit := bits.TrailingZeros8(b)
+			res = append(res, base+uint32(i<<3+bit))
+			b &= b - 1
+		}
+	}
+	return res, nil
+}
+
+func readRoaring(r io.ByteReader, res []uint32) ([]uint32, error) {
+	containers, err := binary.ReadUvarint(r)
+	if err != nil {
+		return res, unexpectedEOF(err)
+	}
+	for c := uint64(0); c < containers; c++ {
+		var key, card uint64
+		if key, err = binary.ReadUvarint(r); err != nil {
+			return res, unexpectedEOF(err)
+		}
+		if card, err = binary.ReadUvarint(r); err != nil {
+			return res, unexpectedEOF(err)
+		}
+		card++
+		if key > 0xFFFF || card > 1<<16 {
+			return res, ErrInvalidFrame
+		}
+		base := uint32(key) << 16
+		if card > roaringArrayMax {
+			if res, err = readBitmap(r, base, 1<<16, res); err != nil {
+				return res, err
+			}
+			continue
+		}
+		for i := uint64(0); i < card; i++ {
+			lo, lErr := r.ReadByte()
+			if lErr != nil {
+				return res, unexpectedEOF(lErr)
+			}
+			hi, hErr := r.ReadByte()
+			if hErr != nil {
+				return res, unexpectedEOF(hErr)
+			}
+			res = append(res, base|uint32(hi)<<8|uint32(lo))
+		}
+	}
+	return res, nil
+}
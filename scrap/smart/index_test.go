@@ -0,0 +1,152 @@
+package smart_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/smart"
+	"github.com/dk-open/crypto-zip/types"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// randomPositions picks sorted positions of updated markets out of n with the given density.
+func randomPositions(rng *rand.Rand, n int, density float64) (res []uint32) {
+	for i := 0; i < n; i++ {
+		if rng.Float64() < density {
+			res = append(res, uint32(i))
+		}
+	}
+	return
+}
+
+func TestIndexEncodingsRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cases := []struct {
+		n       int
+		density float64
+	}{
+		{2000, 0.001}, {2000, 0.05}, {2000, 0.5}, {2000, 0.95}, {200_000, 0.0001}, {200_000, 0.2}, {70_000, 1},
+	}
+	for _, c := range cases {
+		positions := randomPositions(rng, c.n, c.density)
+		for _, tag := range []byte{smart.IndexSkip, smart.IndexBitmap, smart.IndexRoaring} {
+			data := smart.EncodeIndex(tag, positions, c.n)
+			res, err := smart.DecodeIndex(data, uint64(len(positions)), c.n)
+			if err != nil {
+				t.Fatalf("n=%d density=%v tag=%d: %v", c.n, c.density, tag, err)
+			}
+			if !slices.Equal(res, positions) {
+				t.Fatalf("n=%d density=%v tag=%d: positions differ", c.n, c.density, tag)
+			}
+		}
+	}
+}
+
+func TestIndexChoosesCheapest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cases := []struct {
+		n       int
+		span    int
+		density float64
+		tag     byte
+	}{
+		{2000, 2000, 0.005, smart.IndexSkip},
+		{2000, 2000, 0.9, smart.IndexBitmap},
+		// updates clustered in the first container of a large table
+		{1_000_000, 1 << 16, 0.3, smart.IndexRoaring},
+	}
+	for _, c := range cases {
+		positions := randomPositions(rng, c.span, c.density)
+		if tag := smart.ChooseIndex(positions, c.n); tag != c.tag {
+			t.Errorf("n=%d density=%v: expected tag %d, got %d", c.n, c.density, c.tag, tag)
+		}
+		chosen := len(smart.EncodeIndex(smart.ChooseIndex(positions, c.n), positions, c.n))
+		for _, tag := range []byte{smart.IndexSkip, smart.IndexBitmap, smart.IndexRoaring} {
+			if size := len(smart.EncodeIndex(tag, positions, c.n)); size < chosen {
+				t.Errorf("n=%d density=%v: tag %d is cheaper (%d < %d)", c.n, c.density, tag, size, chosen)
+			}
+		}
+	}
+}
+
+// densityProducer updates a random subset of markets with the given density on every tick.
+type densityProducer struct {
+	rng     *rand.Rand
+	names   []string
+	density float64
+	tick    float64
+}
+
+func (p *densityProducer) Prices(w scrap.IPriceWriter) error {
+	p.tick++
+	for _, name := range p.names {
+		if p.rng.Float64() < p.density {
+			if err := w.Write(name, 100+p.tick/100, 100.01+p.tick/100); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BenchmarkScrapDensity measures frames for a Binance sized market table.
+// Measured densities of 1s ticks are around 5-30% and reach 90% on volatile minutes.
+func BenchmarkScrapDensity(b *testing.B) {
+	const numMarkets = 2000
+	ctx := context.Background()
+
+	for _, density := range []float64{0.001, 0.01, 0.05, 0.3, 0.9} {
+		b.Run(fmt.Sprintf("Density=%v", density), func(b *testing.B) {
+			markets := make(map[uint32]types.Market, numMarkets)
+			names := make([]string, 0, numMarkets)
+			for i := 0; i < numMarkets; i++ {
+				name := fmt.Sprintf("M%d", i)
+				markets[uint32(i)] = types.Market{Name: name, Precision: 100}
+				names = append(names, name)
+			}
+			p := &densityProducer{rng: rand.New(rand.NewSource(1)), names: names, density: 1}
+			s := smart.Scraper(markets, p.Prices)
+
+			var buf bytes.Buffer
+			if err := s.Scrap(ctx, &buf); err != nil {
+				b.Fatal(err)
+			}
+			p.density = density
+
+			var total int
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := s.Scrap(ctx, &buf); err != nil {
+					b.Fatal(err)
+				}
+				total += buf.Len()
+			}
+			b.ReportMetric(float64(total)/float64(b.N), "bytes/frame")
+			b.ReportAllocs()
+		})
+	}
+}
+
+// BenchmarkIndexEncoding compares index sizes of every encoding for the same positions.
+func BenchmarkIndexEncoding(b *testing.B) {
+	const numMarkets = 2000
+	tags := map[string]byte{"Skip": smart.IndexSkip, "Bitmap": smart.IndexBitmap, "Roaring": smart.IndexRoaring}
+
+	for _, density := range []float64{0.001, 0.01, 0.05, 0.3, 0.9} {
+		positions := randomPositions(rand.New(rand.NewSource(1)), numMarkets, density)
+		for name, tag := range tags {
+			b.Run(fmt.Sprintf("Density=%v/%s", density, name), func(b *testing.B) {
+				var size int
+				for i := 0; i < b.N; i++ {
+					size = len(smart.EncodeIndex(tag, positions, numMarkets))
+				}
+				b.ReportMetric(float64(size), "bytes/index")
+				b.ReportAllocs()
+			})
+		}
+	}
+}
@@ -6,60 +6,183 @@ import (
 	"github.com/dk-open/crypto-zip/compress"
 	"github.com/dk-open/crypto-zip/scrap"
 	"github.com/dk-open/crypto-zip/types"
-	"sort"
+	"time"
 )
 
 type marketPrice struct {
-	id        uint32
-	precision float64
-	bid       float64
-	ask       float64
-	updated   bool
+	id         uint32
+	precision  float64
+	minMove    float64
+	maxSilence time.Duration
+	// bid and ask are the last emitted prices quantized to the market precision.
+	bid     uint64
+	ask     uint64
+	emitted time.Time
+	// dirty marks the market as changed during the current tick. It is cleared by Scrap once emitted.
+	dirty bool
+}
+
+// IScrapper is a scrapper whose market set can change between ticks.
+type IScrapper interface {
+	scrap.IScrapper
+	// Table returns the market table frames are currently written against.
+	Table() *MarketTable
+	// Writer returns the writer prices are written to. It can be fed by streams between Scrap calls.
+	Writer() scrap.IPriceWriter
+	// SetMarkets replaces the market set. A new table version followed by a key frame
+	// is written on the next Scrap if the set has changed.
+	SetMarkets(markets map[uint32]types.Market)
 }
 
 type scrapper struct {
 	markets []*marketPrice
-	writer  scrap.IPriceWriter
+	writer  *priceWriter
 	f       func(w scrap.IPriceWriter) error
+	table   *MarketTable
+	// tableWritten is false until the current table record is emitted
+	tableWritten bool
+	keyed        bool
+	// positions of markets emitted in the current frame, reused between ticks
+	positions []uint32
+	// values marks a scrapper of value series whose markets hold a single zigzag encoded value in bid
+	values bool
+	// clock stamps frames with the exchange time when set
+	clock scrap.IClock
+}
+
+type Option func(s *scrapper)
+
+// WithClock overrides the time source used to stamp frames and evaluate max silence intervals.
+func WithClock(now func() time.Time) Option {
+	return func(s *scrapper) {
+		s.writer.now = now
+	}
 }
 
-func Scraper(markets map[uint32]types.Market, f func(w scrap.IPriceWriter) error) scrap.IScrapper {
+// WithExchangeClock stamps frames with the exchange time corrected by the clock offset along with the local time.
+func WithExchangeClock(clock scrap.IClock) Option {
+	return func(s *scrapper) {
+		s.clock = clock
+	}
+}
+
+// Scraper creates a scrapper polling prices with f before every frame. The f may be nil when prices are streamed to the Writer.
+// It emits the market table and a key frame on the first Scrap and diff frames afterwards.
+func Scraper(markets map[uint32]types.Market, f func(w scrap.IPriceWriter) error, opts ...Option) IScrapper {
+	res := &scrapper{
+		f:      f,
+		writer: &priceWriter{now: time.Now},
+	}
+	res.SetMarkets(markets)
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+func (s *scrapper) Table() *MarketTable {
+	s.writer.mu.Lock()
+	defer s.writer.mu.Unlock()
+	return s.table
+}
+
+func (s *scrapper) Writer() scrap.IPriceWriter {
+	return s.writer
+}
+
+func (s *scrapper) SetMarkets(markets map[uint32]types.Market) {
+	s.writer.mu.Lock()
+	defer s.writer.mu.Unlock()
+
+	var version uint32 = 1
+	if s.table != nil {
+		version = s.table.Version + 1
+	}
+	table := NewMarketTable(version, markets)
+	if s.table != nil && s.table.Hash == table.Hash {
+		return
+	}
+
 	scrapMap := make(map[string]*marketPrice, len(markets))
 	scrapMarkets := make([]*marketPrice, 0, len(markets))
-	for id, m := range markets {
-		mp := &marketPrice{id: id, precision: m.Precision}
+	for _, tm := range table.Markets {
+		m := markets[tm.ID]
+		mp := &marketPrice{id: tm.ID, precision: m.Precision, minMove: m.MinMove, maxSilence: m.MaxSilence}
+		// Keep the state of markets which did not change
+		if prev, ok := s.writer.markets[m.Name]; ok && prev.id == tm.ID && prev.precision == m.Precision {
+			mp.bid, mp.ask, mp.emitted, mp.dirty = prev.bid, prev.ask, prev.emitted, prev.dirty
+		}
 		scrapMap[m.Name] = mp
 		scrapMarkets = append(scrapMarkets, mp)
 	}
-	sort.Slice(scrapMarkets, func(i, j int) bool {
-		return scrapMarkets[i].id < scrapMarkets[j].id
-	})
-
-	return &scrapper{
-		f:       f,
-		markets: scrapMarkets,
-		writer:  PriceWriter(scrapMap),
-	}
+
+	s.table = table
+	s.tableWritten = false
+	s.keyed = false
+	s.markets = scrapMarkets
+	s.writer.markets = scrapMap
 }
 
 func (s *scrapper) Scrap(ctx context.Context, buf *bytes.Buffer) error {
-	if err := s.f(s.writer); err != nil {
+	if s.f != nil {
+		if err := s.f(s.writer); err != nil {
+			return err
+		}
+	}
+	s.writer.mu.Lock()
+	defer s.writer.mu.Unlock()
+
+	if !s.tableWritten {
+		if err := s.table.write(buf); err != nil {
+			return err
+		}
+		s.tableWritten = true
+	}
+
+	h := frameHeader{kind: FrameDiff, version: s.table.Version, ts: s.writer.now().UnixMilli()}
+	if s.clock != nil {
+		h.clocked, h.offset = true, s.clock.Offset().Milliseconds()
+	}
+	switch {
+	case s.values && s.keyed:
+		h.kind = FrameValueDiff
+	case s.values:
+		h.kind = FrameValueKey
+	case !s.keyed:
+		h.kind = FrameKey
+	}
+	s.positions = s.positions[:0]
+	for i, m := range s.markets {
+		if m.emit(h.key()) {
+			s.positions = append(s.positions, uint32(i))
+		}
+	}
+	h.count = uint64(len(s.positions))
+	if err := writeFrameHeader(buf, h); err != nil {
+		return err
+	}
+	if err := writeIndex(buf, chooseIndex(s.positions, len(s.markets)), s.positions, len(s.markets)); err != nil {
 		return err
 	}
 
-	var index uint64
-	for _, m := range s.markets {
-		if m.updated {
-			if err := compress.WriteVariant(buf, index); err != nil {
+	for _, i := range s.positions {
+		m := s.markets[i]
+		m.dirty = false
+		if s.values {
+			if err := compress.WriteVariant(buf, m.bid); err != nil {
 				return err
 			}
-			if err := compress.PackPrice(buf, uint64(m.bid*m.precision), uint64(m.ask*m.precision)-uint64(m.bid*m.precision)); err != nil {
-				return err
-			}
-			index = 0
 			continue
 		}
-		index++
+		if err := compress.PackPrice(buf, m.bid, m.ask-m.bid); err != nil {
+			return err
+		}
 	}
+	s.keyed = true
 	return nil
 }
+
+// emit reports whether the market belongs to a key or a diff frame.
+func (mp *marketPrice) emit(key bool) bool {
+	return mp.dirty || key && !mp.emitted.IsZero()
+}
@@ -0,0 +1,109 @@
+package smart_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/smart"
+	"github.com/dk-open/crypto-zip/types"
+	"testing"
+)
+
+type quote struct {
+	name     string
+	bid, ask float64
+}
+
+// fakeProducer writes the next batch of quotes on every call.
+type fakeProducer struct {
+	ticks [][]quote
+	tick  int
+}
+
+func (p *fakeProducer) Prices(w scrap.IPriceWriter) error {
+	if p.tick >= len(p.ticks) {
+		return nil
+	}
+	for _, q := range p.ticks[p.tick] {
+		if err := w.Write(q.name, q.bid, q.ask); err != nil {
+			return err
+		}
+	}
+	p.tick++
+	return nil
+}
+
+type entry = smart.FrameEntry
+
+func decodeEntries(t *testing.T, data []byte, n int) []entry {
+	t.Helper()
+	_, _, res, err := smart.DecodeFrame(data, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func testMarkets() map[uint32]types.Market {
+	return map[uint32]types.Market{
+		1: {Name: "BTCUSDT", Precision: 100},
+		2: {Name: "ETHUSDT", Precision: 100},
+		3: {Name: "XRPUSDT", Precision: 10000},
+	}
+}
+
+func scrapTicks(t *testing.T, p *fakeProducer) [][]entry {
+	t.Helper()
+	ctx := context.Background()
+	s := smart.Scraper(testMarkets(), p.Prices)
+
+	var res [][]entry
+	for range p.ticks {
+		var buf bytes.Buffer
+		if err := s.Scrap(ctx, &buf); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, decodeEntries(t, buf.Bytes(), len(testMarkets())))
+	}
+	return res
+}
+
+func TestScrapNotWrittenMarketIsNotReEmitted(t *testing.T) {
+	frames := scrapTicks(t, &fakeProducer{ticks: [][]quote{
+		{{"BTCUSDT", 60000.01, 60000.02}, {"XRPUSDT", 0.5123, 0.5124}},
+		{{"XRPUSDT", 0.5125, 0.5126}},
+		{},
+	}})
+
+	if len(frames[0]) != 2 || frames[0][0] != (entry{0, 6000001, 1}) || frames[0][1] != (entry{2, 5123, 1}) {
+		t.Fatalf("unexpected first frame %v", frames[0])
+	}
+	if len(frames[1]) != 1 || frames[1][0] != (entry{2, 5125, 1}) {
+		t.Fatalf("stale market emitted in second frame %v", frames[1])
+	}
+	if len(frames[2]) != 0 {
+		t.Fatalf("expected empty frame, got %v", frames[2])
+	}
+}
+
+func TestScrapRepeatedWriteWithinTickIsEmitted(t *testing.T) {
+	frames := scrapTicks(t, &fakeProducer{ticks: [][]quote{
+		{{"ETHUSDT", 2500.5, 2500.6}},
+		{{"ETHUSDT", 2501, 2501.1}, {"ETHUSDT", 2501, 2501.1}},
+	}})
+
+	if len(frames[1]) != 1 || frames[1][0] != (entry{1, 250100, 10}) {
+		t.Fatalf("market written twice with the same price was suppressed: %v", frames[1])
+	}
+}
+
+func TestScrapUnchangedPriceIsSuppressed(t *testing.T) {
+	frames := scrapTicks(t, &fakeProducer{ticks: [][]quote{
+		{{"ETHUSDT", 2500.5, 2500.6}, {"BTCUSDT", 60000, 60000.5}},
+		{{"ETHUSDT", 2500.5, 2500.6}, {"BTCUSDT", 60000.001, 60000.5}},
+	}})
+
+	if len(frames[1]) != 0 {
+		t.Fatalf("unchanged prices emitted: %v", frames[1])
+	}
+}
@@ -0,0 +1,180 @@
+package smart
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/dk-open/crypto-zip/compress"
+	"github.com/dk-open/crypto-zip/types"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+)
+
+// RecordTable is the kind of the record carrying the market table frames refer to.
+const RecordTable byte = 3
+
+var ErrTableMismatch = errors.New("smart: frame written against unknown market table")
+
+// TableMarket is a market of the table. Frames refer to markets by their position in the table.
+type TableMarket struct {
+	ID        uint32  `json:"id"`
+	Name      string  `json:"name"`
+	Precision float64 `json:"precision"`
+}
+
+// MarketTable is the versioned set of markets frames are written against.
+type MarketTable struct {
+	Version uint32        `json:"version"`
+	Hash    uint64        `json:"hash"`
+	Markets []TableMarket `json:"markets"`
+}
+
+// NewMarketTable creates the table of markets sorted by id.
+func NewMarketTable(version uint32, markets map[uint32]types.Market) *MarketTable {
+	res := &MarketTable{Version: version, Markets: make([]TableMarket, 0, len(markets))}
+	for id, m := range markets {
+		res.Markets = append(res.Markets, TableMarket{ID: id, Name: m.Name, Precision: m.Precision})
+	}
+	sort.Slice(res.Markets, func(i, j int) bool {
+		return res.Markets[i].ID < res.Markets[j].ID
+	})
+	res.Hash = res.hash()
+	return res
+}
+
+// hash returns FNV-1a of the encoded markets. It does not depend on the version.
+func (t *MarketTable) hash() uint64 {
+	var buf bytes.Buffer
+	_ = t.writeMarkets(&buf)
+	h := fnv.New64a()
+	_, _ = h.Write(buf.Bytes())
+	return h.Sum64()
+}
+
+// Types returns markets of the table keyed by id.
+func (t *MarketTable) Types() map[uint32]types.Market {
+	res := make(map[uint32]types.Market, len(t.Markets))
+	for _, m := range t.Markets {
+		res[m.ID] = types.Market{Name: m.Name, Precision: m.Precision}
+	}
+	return res
+}
+
+// WriteTo writes the table record.
+func (t *MarketTable) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if err := t.write(&buf); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(w)
+}
+
+func (t *MarketTable) write(buf *bytes.Buffer) error {
+	if err := buf.WriteByte(RecordTable); err != nil {
+		return err
+	}
+	if err := compress.WriteVariant(buf, uint64(t.Version)); err != nil {
+		return err
+	}
+	var hash [8]byte
+	binary.LittleEndian.PutUint64(hash[:], t.Hash)
+	if _, err := buf.Write(hash[:]); err != nil {
+		return err
+	}
+	return t.writeMarkets(buf)
+}
+
+func (t *MarketTable) writeMarkets(buf *bytes.Buffer) error {
+	if err := compress.WriteVariant(buf, uint64(len(t.Markets))); err != nil {
+		return err
+	}
+	var precision [8]byte
+	for _, m := range t.Markets {
+		if err := compress.WriteVariant(buf, uint64(m.ID)); err != nil {
+			return err
+		}
+		if err := compress.WriteVariant(buf, uint64(len(m.Name))); err != nil {
+			return err
+		}
+		if _, err := buf.WriteString(m.Name); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(precision[:], math.Float64bits(m.Precision))
+		if _, err := buf.Write(precision[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadMarketTable reads a table record written by WriteTo.
+func ReadMarketTable(r io.Reader) (*MarketTable, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	kind, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if kind != RecordTable {
+		return nil, ErrInvalidFrame
+	}
+	return readTable(br)
+}
+
+// readTable reads the table record following its kind byte and validates its hash.
+func readTable(r io.ByteReader) (*MarketTable, error) {
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	var hash [8]byte
+	for i := range hash {
+		if hash[i], err = r.ReadByte(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	res := &MarketTable{Version: uint32(version), Hash: binary.LittleEndian.Uint64(hash[:])}
+	var prev uint32
+	for i := uint64(0); i < count; i++ {
+		var m TableMarket
+		var id, size uint64
+		if id, err = binary.ReadUvarint(r); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if size, err = binary.ReadUvarint(r); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if id > math.MaxUint32 || size > 1024 || i > 0 && uint32(id) <= prev {
+			return nil, ErrInvalidFrame
+		}
+		name := make([]byte, size)
+		for j := range name {
+			if name[j], err = r.ReadByte(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+		var precision [8]byte
+		for j := range precision {
+			if precision[j], err = r.ReadByte(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+		m.ID, m.Name, m.Precision = uint32(id), string(name), math.Float64frombits(binary.LittleEndian.Uint64(precision[:]))
+		res.Markets = append(res.Markets, m)
+		prev = m.ID
+	}
+	if res.hash() != res.Hash {
+		return nil, ErrInvalidFrame
+	}
+	return res, nil
+}
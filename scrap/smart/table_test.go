@@ -0,0 +1,93 @@
+package smart_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/scrap/smart"
+	"github.com/dk-open/crypto-zip/types"
+	"reflect"
+	"testing"
+)
+
+func TestMarketTableRoundTrip(t *testing.T) {
+	table := smart.NewMarketTable(7, testMarkets())
+
+	var buf bytes.Buffer
+	if _, err := table.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	res, err := smart.ReadMarketTable(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(table, res) {
+		t.Fatalf("expected %+v, got %+v", table, res)
+	}
+	if smart.NewMarketTable(8, testMarkets()).Hash != table.Hash {
+		t.Errorf("hash must not depend on the version")
+	}
+}
+
+func TestScrapperWritesTableOnChange(t *testing.T) {
+	ctx := context.Background()
+	p := &fakeProducer{ticks: [][]quote{
+		{{"BTCUSDT", 60000.01, 60000.02}, {"ETHUSDT", 2500.5, 2500.6}},
+		{{"SOLUSDT", 150.25, 150.26}},
+		{{"ETHUSDT", 2501, 2501.1}},
+	}}
+	s := smart.Scraper(testMarkets(), p.Prices)
+	book := smart.NewBook(nil)
+
+	var buf bytes.Buffer
+	if err := s.Scrap(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.ApplyBytes(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged set keeps the table version
+	s.SetMarkets(testMarkets())
+	if s.Table().Version != 1 {
+		t.Fatalf("unexpected table version %d", s.Table().Version)
+	}
+
+	markets := testMarkets()
+	delete(markets, 3)
+	markets[4] = types.Market{Name: "SOLUSDT", Precision: 100}
+	s.SetMarkets(markets)
+	if s.Table().Version != 2 {
+		t.Fatalf("unexpected table version %d", s.Table().Version)
+	}
+
+	buf.Reset()
+	if err := s.Scrap(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Bytes()[0] != smart.RecordTable {
+		t.Fatalf("table record expected after the market set change")
+	}
+
+	// Frames written against the new table can not be applied with the old one
+	var record bytes.Buffer
+	if _, err := s.Table().WriteTo(&record); err != nil {
+		t.Fatal(err)
+	}
+	stale := smart.NewBook(book.Table())
+	if err := stale.ApplyBytes(buf.Bytes()[record.Len():]); !errors.Is(err, smart.ErrTableMismatch) {
+		t.Fatalf("expected ErrTableMismatch, got %v", err)
+	}
+
+	if err := book.ApplyBytes(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[uint32]types.Price{
+		1: {60000.01, 60000.02},
+		2: {2500.5, 2500.6},
+		4: {150.25, 150.26},
+	}
+	if snapshot := book.Snapshot(); !reflect.DeepEqual(snapshot, expected) {
+		t.Fatalf("expected %v, got %v", expected, snapshot)
+	}
+}
@@ -0,0 +1,90 @@
+package smart
+
+import (
+	"bytes"
+	"context"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/types"
+	"math"
+)
+
+// IValueScrapper is a scrapper of a value series, like mark prices or funding rates, whose market set can change between ticks.
+type IValueScrapper interface {
+	scrap.IScrapper
+	Table() *MarketTable
+	// Writer returns the writer values are written to. It can be fed by streams between Scrap calls.
+	Writer() scrap.IValueWriter
+	SetMarkets(markets map[uint32]types.Market)
+}
+
+type valueScrapper struct {
+	*scrapper
+	f func(w scrap.IValueWriter) error
+}
+
+// ValueScraper creates a scrapper of a value series polled with f before every frame. Values may be negative.
+// Frames are written like the ones of Scraper with a single value per entry, MinMove and MaxSilence of markets apply to values.
+func ValueScraper(markets map[uint32]types.Market, f func(w scrap.IValueWriter) error, opts ...Option) IValueScrapper {
+	s := Scraper(markets, nil, opts...).(*scrapper)
+	s.values = true
+	return &valueScrapper{scrapper: s, f: f}
+}
+
+func (s *valueScrapper) Writer() scrap.IValueWriter {
+	return (*valueWriter)(s.writer)
+}
+
+func (s *valueScrapper) Scrap(ctx context.Context, buf *bytes.Buffer) error {
+	if s.f != nil {
+		if err := s.f(s.Writer()); err != nil {
+			return err
+		}
+	}
+	return s.scrapper.Scrap(ctx, buf)
+}
+
+// valueWriter stores values quantized to the market precision and zigzag encoded in the bid of markets.
+type valueWriter priceWriter
+
+func (w *valueWriter) Write(name string, value float64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if mp, ok := w.markets[name]; ok {
+		q := zigzag(int64(math.Round(value * mp.precision)))
+		now := w.now()
+		if mp.valueChanged(q) || mp.silent(now) {
+			mp.dirty = true
+			mp.bid = q
+			mp.emitted = now
+		}
+	}
+	return nil
+}
+
+// valueChanged reports whether the quantized value moved enough from the last emitted one.
+// Unlike prices, a zero value is valid and is emitted when the market has no value yet.
+func (mp *marketPrice) valueChanged(v uint64) bool {
+	if mp.emitted.IsZero() {
+		return true
+	}
+	if v == mp.bid {
+		return false
+	}
+	if mp.minMove <= 0 {
+		return true
+	}
+	prev, next := float64(unzigzag(mp.bid)), float64(unzigzag(v))
+	if prev == 0 {
+		return true
+	}
+	return math.Abs(next-prev)/math.Abs(prev) >= mp.minMove
+}
+
+// zigzag maps signed integers to unsigned ones keeping small magnitudes small, as binary.PutVarint does.
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -0,0 +1,110 @@
+package smart_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/dk-open/crypto-zip/scrap"
+	"github.com/dk-open/crypto-zip/scrap/smart"
+	"github.com/dk-open/crypto-zip/types"
+	"testing"
+)
+
+type value struct {
+	name  string
+	value float64
+}
+
+// fakeValues writes the next batch of values on every call.
+type fakeValues struct {
+	ticks [][]value
+	tick  int
+}
+
+func (p *fakeValues) Values(w scrap.IValueWriter) error {
+	if p.tick >= len(p.ticks) {
+		return nil
+	}
+	for _, v := range p.ticks[p.tick] {
+		if err := w.Write(v.name, v.value); err != nil {
+			return err
+		}
+	}
+	p.tick++
+	return nil
+}
+
+func fundingMarkets() map[uint32]types.Market {
+	return map[uint32]types.Market{
+		1: {Name: "BTCUSDT", Precision: 1e8},
+		2: {Name: "ETHUSDT", Precision: 1e8},
+		3: {Name: "XRPUSDT", Precision: 1e8, MinMove: 0.1},
+	}
+}
+
+func TestValueScraper(t *testing.T) {
+	ctx := context.Background()
+	p := &fakeValues{ticks: [][]value{
+		{{"BTCUSDT", 0.0001}, {"ETHUSDT", -0.00002341}, {"XRPUSDT", 0.0001}},
+		{{"BTCUSDT", 0.0001}, {"ETHUSDT", 0.00000125}, {"XRPUSDT", 0.000105}},
+		{{"BTCUSDT", -0.0001}, {"XRPUSDT", 0.00012}},
+	}}
+	s := smart.ValueScraper(fundingMarkets(), p.Values)
+	book := smart.NewBook(nil)
+
+	expected := []map[uint32]float64{
+		{1: 0.0001, 2: -0.00002341, 3: 0.0001},
+		// Unchanged BTC and the XRP move below MinMove are not emitted
+		{1: 0.0001, 2: 0.00000125, 3: 0.0001},
+		{1: -0.0001, 2: 0.00000125, 3: 0.00012},
+	}
+	var frame bytes.Buffer
+	for i := range p.ticks {
+		frame.Reset()
+		if err := s.Scrap(ctx, &frame); err != nil {
+			t.Fatal(err)
+		}
+		// A frame header with the millisecond timestamp, an index and a single value
+		if i == 1 && frame.Len() > 16 {
+			t.Errorf("diff frame of a single value takes %d bytes", frame.Len())
+		}
+		if err := book.ApplyBytes(frame.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		values := book.Values()
+		for id, v := range expected[i] {
+			if values[id] != v {
+				t.Errorf("tick %d market %d: expected %v, got %v", i, id, v, values[id])
+			}
+		}
+	}
+	if v, ts := book.Value(2); v != 0.00000125 || ts.IsZero() {
+		t.Errorf("unexpected market 2 value %v at %v", v, ts)
+	}
+}
+
+func TestValueFramesRequireKeyFrame(t *testing.T) {
+	ctx := context.Background()
+	s := smart.ValueScraper(fundingMarkets(), nil)
+	_ = s.Writer().Write("BTCUSDT", 0.0001)
+
+	var key, diff bytes.Buffer
+	if err := s.Scrap(ctx, &key); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Writer().Write("BTCUSDT", -0.0002)
+	if err := s.Scrap(ctx, &diff); err != nil {
+		t.Fatal(err)
+	}
+
+	book := smart.NewBook(s.Table())
+	if err := book.ApplyBytes(diff.Bytes()); !errors.Is(err, smart.ErrNoKeyFrame) {
+		t.Fatalf("expected ErrNoKeyFrame, got %v", err)
+	}
+	if err := book.ApplyBytes(append(key.Bytes(), diff.Bytes()...)); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := book.Value(1); v != -0.0002 {
+		t.Errorf("unexpected value %v", v)
+	}
+}
@@ -1,24 +1,63 @@
 package smart
 
-import "github.com/dk-open/crypto-zip/scrap"
+import (
+	"github.com/dk-open/crypto-zip/scrap"
+	"math"
+	"sync"
+	"time"
+)
 
+// priceWriter is safe for concurrent use, so streams can write while frames are scrapped.
 type priceWriter struct {
+	mu      sync.Mutex
 	markets map[string]*marketPrice
+	now     func() time.Time
 }
 
 func PriceWriter(marketsMap map[string]*marketPrice) scrap.IPriceWriter {
-	return &priceWriter{markets: marketsMap}
+	return &priceWriter{markets: marketsMap, now: time.Now}
 }
 
 func (w *priceWriter) Write(name string, bid, ask float64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	if mp, ok := w.markets[name]; ok {
-		if mp.bid != bid || mp.ask != ask {
-			mp.updated = true
-		} else {
-			mp.updated = false
+		qBid, qAsk := mp.quantize(bid), mp.quantize(ask)
+		now := w.now()
+		if mp.changed(qBid, qAsk) || mp.silent(now) {
+			mp.dirty = true
+			mp.bid = qBid
+			mp.ask = qAsk
+			mp.emitted = now
 		}
-		mp.bid = bid
-		mp.ask = ask
 	}
 	return nil
 }
+
+// quantize converts the price to the integer number of market ticks.
+func (mp *marketPrice) quantize(v float64) uint64 {
+	return uint64(math.Round(v * mp.precision))
+}
+
+// changed reports whether the quantized prices moved enough from the last emitted ones.
+func (mp *marketPrice) changed(bid, ask uint64) bool {
+	if bid == mp.bid && ask == mp.ask {
+		return false
+	}
+	if mp.emitted.IsZero() || mp.minMove <= 0 {
+		return true
+	}
+	return relativeMove(mp.bid, bid) >= mp.minMove || relativeMove(mp.ask, ask) >= mp.minMove
+}
+
+// silent reports whether the market has not been emitted for longer than its max silence interval.
+func (mp *marketPrice) silent(now time.Time) bool {
+	return mp.maxSilence > 0 && !mp.emitted.IsZero() && now.Sub(mp.emitted) >= mp.maxSilence
+}
+
+func relativeMove(prev, next uint64) float64 {
+	if prev == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(float64(next)-float64(prev)) / float64(prev)
+}
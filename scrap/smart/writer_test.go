@@ -0,0 +1,61 @@
+package smart_test
+
+import (
+	"github.com/dk-open/crypto-zip/scrap/smart"
+	"github.com/dk-open/crypto-zip/types"
+	"testing"
+	"time"
+)
+
+// clockWrite is a write at the offset from the start of the test clock, and the market state emitted after it.
+type clockWrite struct {
+	at       time.Duration
+	bid, ask float64
+	// bidTicks, askTicks and emittedAt are the expected emitted state
+	bidTicks, askTicks uint64
+	emittedAt          time.Duration
+}
+
+// writeClock writes prices of the market with the writer clock set to the write time.
+func writeClock(t *testing.T, m types.Market, writes []clockWrite) {
+	t.Helper()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	w, emitted := smart.ClockWriter(map[string]types.Market{m.Name: m}, func() time.Time { return now })
+	for i, write := range writes {
+		now = start.Add(write.at)
+		if err := w.Write(m.Name, write.bid, write.ask); err != nil {
+			t.Fatal(err)
+		}
+		expected := smart.Emitted{Bid: write.bidTicks, Ask: write.askTicks, At: start.Add(write.emittedAt)}
+		if got := emitted(m.Name); got != expected {
+			t.Errorf("write %d: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
+
+func TestWriterSuppressesSubTickNoise(t *testing.T) {
+	writeClock(t, types.Market{Name: "BTCUSDT", Precision: 100}, []clockWrite{
+		{at: 0, bid: 1.001, ask: 1.011, bidTicks: 100, askTicks: 101, emittedAt: 0},
+		// Moves within the tick quantize to the emitted prices
+		{at: time.Second, bid: 1.004, ask: 1.012, bidTicks: 100, askTicks: 101, emittedAt: 0},
+		{at: 2 * time.Second, bid: 1.006, ask: 1.012, bidTicks: 101, askTicks: 101, emittedAt: 2 * time.Second},
+	})
+}
+
+func TestWriterDropsMovesBelowMinMove(t *testing.T) {
+	writeClock(t, types.Market{Name: "BTCUSDT", Precision: 100, MinMove: 0.01}, []clockWrite{
+		{at: 0, bid: 100, ask: 101, bidTicks: 10000, askTicks: 10100, emittedAt: 0},
+		{at: time.Second, bid: 100.6, ask: 101, bidTicks: 10000, askTicks: 10100, emittedAt: 0},
+		// The move is measured from the emitted price, not from the last written one
+		{at: 2 * time.Second, bid: 101, ask: 101.5, bidTicks: 10100, askTicks: 10150, emittedAt: 2 * time.Second},
+	})
+}
+
+func TestWriterMaxSilenceForcesRefresh(t *testing.T) {
+	writeClock(t, types.Market{Name: "BTCUSDT", Precision: 1, MaxSilence: time.Minute}, []clockWrite{
+		{at: 0, bid: 100, ask: 101, bidTicks: 100, askTicks: 101, emittedAt: 0},
+		{at: 30 * time.Second, bid: 100, ask: 101, bidTicks: 100, askTicks: 101, emittedAt: 0},
+		{at: time.Minute, bid: 100, ask: 101, bidTicks: 100, askTicks: 101, emittedAt: time.Minute},
+	})
+}
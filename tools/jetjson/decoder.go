@@ -14,7 +14,8 @@ type IDecoder[T any] interface {
 }
 
 type decoder[T any] struct {
-	iter *Iterator
+	iter  *Iterator
+	keyed bool
 }
 
 func Decoder[T any](buf io.Reader, level int) IDecoder[T] {
@@ -25,6 +26,51 @@ func Decoder[T any](buf io.Reader, level int) IDecoder[T] {
 	return res
 }
 
+// MapDecoder iterates an object whose values are items keyed by name, like {"XXBTZUSD":{...},"XETHZUSD":{...}}.
+// The string field tagged `jetjson:"key"` receives the key of the item. An array value of a float64
+// field is read as its first element, so {"a":["52609.6","1","1.000"]} sets the field to 52609.6.
+func MapDecoder[T any](buf io.Reader, level int) IDecoder[T] {
+	res := &decoder[T]{iter: NewIterator(buf), keyed: true}
+	for i := 0; i < level; i++ {
+		res.Next()
+	}
+	return res
+}
+
+type fieldsDecoder struct {
+	decoder[[]string]
+	names []string
+}
+
+// FieldsDecoder iterates items like Decoder, reading fields of names given at run time instead of struct fields.
+// The item holds values in the order of names with strings unquoted, missing fields are empty.
+// The item is reused between callbacks.
+func FieldsDecoder(buf io.Reader, level int, names ...string) IDecoder[[]string] {
+	res := &fieldsDecoder{decoder: decoder[[]string]{iter: NewIterator(buf)}, names: names}
+	for i := 0; i < level; i++ {
+		res.Next()
+	}
+	return res
+}
+
+func (s *fieldsDecoder) Read(callBack func(item []string) error) error {
+	item := make([]string, len(s.names))
+	fieldUpdater := make(map[string]func([]byte), len(s.names))
+	for i, name := range s.names {
+		fieldUpdater[name] = func(data []byte) {
+			if len(data) > 1 && data[0] == '"' {
+				data = data[1 : len(data)-1]
+			}
+			item[i] = string(data)
+		}
+	}
+	return s.readItems(fieldUpdater, func() error {
+		err := callBack(item)
+		clear(item)
+		return err
+	})
+}
+
 func stringUpdater(addr unsafe.Pointer) func(data []byte) {
 	var stringVal [64]byte
 
@@ -51,6 +97,7 @@ func (s *decoder[T]) Next() bool {
 
 func (s *decoder[T]) Read(callBack func(item T) error) error {
 	fieldUpdater := map[string]func([]byte){}
+	var keyUpdater func([]byte)
 
 	var item T
 	vv := reflect.ValueOf(&item).Elem()
@@ -61,6 +108,10 @@ func (s *decoder[T]) Read(callBack func(item T) error) error {
 		if tag := fl.Tag.Get("json"); tag != "" {
 			fName = tag
 		}
+		if fl.Tag.Get("jetjson") == "key" && fl.Type.Kind() == reflect.String {
+			keyUpdater = stringUpdater(unsafe.Pointer(vv.Field(i).UnsafeAddr()))
+			continue
+		}
 
 		switch fl.Type.Kind() {
 		case reflect.String:
@@ -72,10 +123,22 @@ func (s *decoder[T]) Read(callBack func(item T) error) error {
 			panic("unhandled default case")
 		}
 	}
+	if s.keyed {
+		return s.readMap(&item, keyUpdater, fieldUpdater, callBack)
+	}
+	return s.readItems(fieldUpdater, func() error {
+		return callBack(item)
+	})
+}
+
+// readItems reads fields of every item and calls emit once the item is read.
+func (s *decoder[T]) readItems(fieldUpdater map[string]func([]byte), emit func() error) error {
 	num := len(fieldUpdater)
 	var matched int
 	for s.iter.Start() {
 		matched = num
+		// closed is set once the item ends before all fields are matched, or with the last one
+		closed := false
 		for matched > 0 {
 			if key, kok := s.iter.ReadKey(); kok {
 
@@ -91,11 +154,97 @@ func (s *decoder[T]) Read(callBack func(item T) error) error {
 			if noNext := s.iter.Next(); !noNext {
 				break
 			}
+			if closed = s.iter.closed(); closed {
+				break
+			}
 		}
-		if err := callBack(item); err != nil {
+		if err := emit(); err != nil {
 			return err
 		}
-		s.iter.End()
+		if !closed {
+			s.iter.End()
+		}
 	}
 	return nil
 }
+
+func (s *decoder[T]) readMap(item *T, keyUpdater func([]byte), fieldUpdater map[string]func([]byte), callBack func(item T) error) error {
+	var empty T
+	for {
+		c := s.iter.skipEmpty()
+		if c == ',' {
+			s.iter.head++
+			continue
+		}
+		if c != '"' {
+			return nil
+		}
+		key, ok := s.iter.ReadKey()
+		if !ok {
+			return nil
+		}
+		*item = empty
+		if keyUpdater != nil {
+			keyUpdater(key)
+		}
+		if s.iter.skipEmpty() != '{' {
+			if !s.iter.SkipValue() {
+				return nil
+			}
+			continue
+		}
+		s.iter.head++
+		if !s.readObject(fieldUpdater) {
+			return nil
+		}
+		if err := callBack(*item); err != nil {
+			return err
+		}
+	}
+}
+
+// readObject reads fields of an object whose opening bracket is already read, skipping unknown and nested values.
+func (s *decoder[T]) readObject(fieldUpdater map[string]func([]byte)) bool {
+	for {
+		switch s.iter.skipEmpty() {
+		case nul:
+			return false
+		case '}':
+			s.iter.head++
+			return true
+		case ',':
+			s.iter.head++
+			continue
+		}
+		key, ok := s.iter.ReadKey()
+		if !ok {
+			return false
+		}
+		fu, found := fieldUpdater[types.BytesToString(key[1:len(key)-1])]
+		if !found {
+			if !s.iter.SkipValue() {
+				return false
+			}
+			continue
+		}
+		if s.iter.skipEmpty() == '[' {
+			s.iter.head++
+			if s.iter.skipEmpty() != ']' {
+				val, vok := s.iter.ReadValue()
+				if !vok {
+					return false
+				}
+				fu(val)
+			}
+			if !s.iter.SkipContainer(1) {
+				return false
+			}
+			continue
+		}
+		val, vok := s.iter.ReadValue()
+		if !vok {
+			return false
+		}
+		fu(val)
+	}
+}
@@ -19,9 +19,13 @@ type Iterator struct {
 	p             unsafe.Pointer
 }
 
+// bufSize is the size of chunks read. The buffer has an extra byte for the nul terminator.
+const bufSize = 1500
+
 func NewIterator(reader io.Reader) *Iterator {
 	res := &Iterator{reader: reader,
-		buf: make([]byte, 1500),
+		buf:         make([]byte, bufSize+1),
+		recordStart: -1,
 	}
 	res.p = res.bufptr()
 
@@ -65,6 +69,12 @@ func (b *Iterator) Next() bool {
 	}
 }
 
+// closed reports whether the separator passed by Next closed an object or an array.
+func (b *Iterator) closed() bool {
+	c := char(b.p, b.head-1)
+	return c == '}' || c == ']'
+}
+
 func (b *Iterator) End() bool {
 	for {
 		switch char(b.p, b.head) {
@@ -129,12 +139,12 @@ func (b *Iterator) takeValue() (res []byte) {
 	if b.recordBufSize > 0 {
 		//b.recordStart = 0
 		copy(b.recordBuf[b.recordBufSize:], b.buf[:b.head])
-		b.recordStart = 0
+		b.recordStart = -1
 		return b.recordBuf[:b.recordBufSize+b.head]
 		//return append(b.recordBuf[:b.recordBufSize], b.buf[:b.head]...)
 	}
 	res = b.buf[b.recordStart:b.head]
-	b.recordStart = 0
+	b.recordStart = -1
 	return res
 }
 
@@ -182,6 +192,8 @@ func (b *Iterator) ReadValue() ([]byte, bool) {
 			if !b.loadMore() {
 				return b.takeValue(), false
 			}
+			c = char(b.p, b.head)
+			continue
 		}
 
 		b.head++
@@ -248,14 +260,15 @@ func (b *Iterator) updateRecorder() {
 
 func (b *Iterator) loadMore() bool {
 	//fmt.Println("loadMore")
-	if b.recordStart > 0 {
+	// Keep the part of the value being read, recordStart is negative when nothing is read
+	if b.recordStart >= 0 {
 		if b.recordStart < b.tail {
 			b.updateRecorder()
 		}
 		b.recordStart = 0
 	}
 
-	n, err := b.reader.Read(b.buf[:])
+	n, err := b.reader.Read(b.buf[:bufSize])
 	//fmt.Println("load", n, err)
 	if n == 0 {
 		if err != nil {
@@ -267,9 +280,83 @@ func (b *Iterator) loadMore() bool {
 	} else {
 		b.head = 0
 		b.tail = n
-		if n < 1500 {
-			b.buf[n] = nul
-		}
+		b.buf[n] = nul
 		return true
 	}
 }
+
+// SkipValue skips the next value including nested objects and arrays.
+func (b *Iterator) SkipValue() bool {
+	c := b.skipEmpty()
+	switch c {
+	case nul:
+		return false
+	case '"':
+		b.head++
+		return b.skipString()
+	case '{', '[':
+		b.head++
+		return b.SkipContainer(1)
+	}
+	for {
+		if valueEndTable[c] {
+			return true
+		} else if c == nul {
+			if !b.loadMore() {
+				return false
+			}
+		} else {
+			b.head++
+		}
+		c = char(b.p, b.head)
+	}
+}
+
+// SkipContainer skips the rest of depth nested objects or arrays whose opening brackets are already read.
+func (b *Iterator) SkipContainer(depth int) bool {
+	for depth > 0 {
+		switch char(b.p, b.head) {
+		case '"':
+			b.head++
+			if !b.skipString() {
+				return false
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case nul:
+			if !b.loadMore() {
+				return false
+			}
+			continue
+		}
+		b.head++
+	}
+	return true
+}
+
+// skipString skips the rest of a string whose opening quote is already read.
+func (b *Iterator) skipString() bool {
+	for {
+		switch char(b.p, b.head) {
+		case '\\':
+			b.head++
+			if char(b.p, b.head) == nul {
+				if !b.loadMore() {
+					return false
+				}
+			}
+		case '"':
+			b.head++
+			return true
+		case nul:
+			if !b.loadMore() {
+				return false
+			}
+			continue
+		}
+		b.head++
+	}
+}
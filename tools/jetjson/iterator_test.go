@@ -9,7 +9,9 @@ import (
 	jsoniterGo "github.com/json-iterator/go"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime/pprof"
+	"strings"
 	"testing"
 	"time"
 )
@@ -60,7 +62,7 @@ func TestIterator(t *testing.T) {
 
 func TestCPUProfile(t *testing.T) {
 	// CPU profiling
-	cpuProfile, err := os.Create("cpu.prof")
+	cpuProfile, err := os.Create(filepath.Join(t.TempDir(), "cpu.prof"))
 	if err != nil {
 		t.Fatal("could not create CPU profile: ", err)
 	}
@@ -196,3 +198,78 @@ func BenchmarkIterator(b *testing.B) {
 	})
 	//	github.com/goccy/go-json
 }
+
+type testKeyed struct {
+	Pair string  `jetjson:"key"`
+	Ask  float64 `json:"a"`
+	Bid  float64 `json:"b"`
+	Open float64 `json:"o"`
+}
+
+func TestMapDecoder(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`{"error":[],"result":{`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"PAIR%d":{"a":["%d.5","1","1.000"],"name":"a \"quoted\", {value}","nested":{"x":[[1,2],{"y":"]"}]},"b":["%d.25","1","1.000"],"c":["1.1","0.1"],"o":"%d"}`, i, i, i, i)
+	}
+	sb.WriteString(`}}`)
+
+	var res []testKeyed
+	err := jetjson.MapDecoder[testKeyed](strings.NewReader(sb.String()), 3).Read(func(item testKeyed) error {
+		res = append(res, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 100 {
+		t.Fatalf("expected 100 items, got %d", len(res))
+	}
+	for i, item := range res {
+		expected := testKeyed{Pair: fmt.Sprintf("PAIR%d", i), Ask: float64(i) + 0.5, Bid: float64(i) + 0.25, Open: float64(i)}
+		if item != expected {
+			t.Fatalf("expected %v, got %v", expected, item)
+		}
+	}
+}
+
+func TestFieldsDecoder(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`{"code":0,"data":[`)
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		if i%2 == 0 {
+			fmt.Fprintf(&sb, `{"s":"PAIR%d","vol":"12.5","ask":%d.75,"bid":"%d.5"}`, i, i, i)
+			continue
+		}
+		// Odd items miss the ask
+		fmt.Fprintf(&sb, `{"bid":"%d.5","s":"PAIR%d"}`, i, i)
+	}
+	sb.WriteString(`]}`)
+
+	var res [][]string
+	err := jetjson.FieldsDecoder(strings.NewReader(sb.String()), 2, "s", "bid", "ask").Read(func(item []string) error {
+		res = append(res, append([]string(nil), item...))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 200 {
+		t.Fatalf("expected 200 items, got %d", len(res))
+	}
+	for i, item := range res {
+		expected := []string{fmt.Sprintf("PAIR%d", i), fmt.Sprintf("%d.5", i), ""}
+		if i%2 == 0 {
+			expected[2] = fmt.Sprintf("%d.75", i)
+		}
+		if strings.Join(item, ",") != strings.Join(expected, ",") {
+			t.Fatalf("expected %v, got %v", expected, item)
+		}
+	}
+}
@@ -1,8 +1,17 @@
 package types
 
+import "time"
+
 type Market struct {
 	Name      string
 	Precision float64
+
+	// MinMove is the minimal relative price move (e.g. 0.0001 for 1bp) required to emit an update.
+	// Zero means any change of the quantized price is emitted.
+	MinMove float64
+	// MaxSilence forces a refresh of an unchanged price once the interval has passed since the last emit.
+	// Zero disables forced refreshes.
+	MaxSilence time.Duration
 }
 
 type Price [2]float64
@@ -29,6 +38,16 @@ func (am AssetMap) Add(id AssetID, name string) {
 	am[name] = id
 }
 
+// Alias maps the alias to the asset of the name, e.g. the legacy XXBT code to BTC.
+// It reports false if the name is unknown.
+func (am AssetMap) Alias(alias, name string) bool {
+	id, ok := am[name]
+	if ok {
+		am[alias] = id
+	}
+	return ok
+}
+
 func NewMarket(sell, buy AssetID) (res MarketID) {
 	return MarketID(sell)<<AssetBits | MarketID(buy)
 }